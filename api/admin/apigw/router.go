@@ -74,6 +74,12 @@ func RegisterRoutes(r *gin.Engine) {
 		// ✳️ 傳回原始結果
 		c.Status(resp.StatusCode)
 		c.Header("Content-Type", resp.Header.Get("Content-Type"))
+		// 編輯衝突檢查用的 ETag、匯出檔案的檔名也要帶回去
+		for _, key := range []string{"ETag", "Content-Disposition"} {
+			if value := resp.Header.Get(key); value != "" {
+				c.Header(key, value)
+			}
+		}
 		io.Copy(c.Writer, resp.Body)
 	})
 }
@@ -1,6 +1,8 @@
 package category
 
 import (
+	"blog-backend/common/middleware"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -22,6 +24,11 @@ func (api *CategoryAPI) RegisterRoutes(r *gin.Engine) {
 	{
 		apiGroup.GET("", api.GetCategories)
 		apiGroup.GET("/:id", api.GetCategoryByID)
+		apiGroup.POST("", api.CreateCategory)
+		apiGroup.PATCH("/reorder", api.ReorderCategories)
+		apiGroup.PATCH("/:id", api.UpdateCategory)
+		apiGroup.PATCH("/:id/move", api.MoveCategory)
+		apiGroup.DELETE("/:id", api.DeleteCategory)
 	}
 }
 
@@ -45,3 +52,74 @@ func (api *CategoryAPI) GetCategoryByID(c *gin.Context) {
 	}
 	c.Set("data", category)
 }
+
+// CreateCategory 新增分類
+func (api *CategoryAPI) CreateCategory(c *gin.Context) {
+	var req CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(middleware.ErrValidation)
+		return
+	}
+	category, err := api.service.CreateCategory(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", category)
+}
+
+// UpdateCategory 修改分類名稱或 slug
+func (api *CategoryAPI) UpdateCategory(c *gin.Context) {
+	id := c.Param("id")
+	var req UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(middleware.ErrValidation)
+		return
+	}
+	category, err := api.service.UpdateCategory(id, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", category)
+}
+
+// MoveCategory 將分類移到其他上層分類底下
+func (api *CategoryAPI) MoveCategory(c *gin.Context) {
+	id := c.Param("id")
+	var req MoveCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(middleware.ErrValidation)
+		return
+	}
+	category, err := api.service.MoveCategory(id, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", category)
+}
+
+// ReorderCategories 一次調整同層分類的排序
+func (api *CategoryAPI) ReorderCategories(c *gin.Context) {
+	var req ReorderCategoriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(middleware.ErrValidation)
+		return
+	}
+	if err := api.service.ReorderCategories(req); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// DeleteCategory 刪除分類（必須沒有子分類與文章）
+func (api *CategoryAPI) DeleteCategory(c *gin.Context) {
+	id := c.Param("id")
+	if err := api.service.DeleteCategory(id); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
@@ -3,10 +3,27 @@ package category
 type CreateCategoryRequest struct {
 	Name   string `json:"name" binding:"required"` // 分類名稱，必填
 	Parent *uint  `json:"parent"`                  // 可選的上層分類 ID
+	Slug   string `json:"slug"`                    // 可選，未填時由名稱自動產生
+}
+
+type UpdateCategoryRequest struct {
+	Name *string `json:"name"` // 新的分類名稱，未填則不變
+	Slug *string `json:"slug"` // 新的 slug，未填則不變
+}
+
+type MoveCategoryRequest struct {
+	Parent *uint `json:"parent"` // 新的上層分類 ID，null 表示移到最上層
+}
+
+type ReorderCategoriesRequest struct {
+	Parent *uint  `json:"parent"`                       // 要排序的同層分類所屬的上層分類，null 表示最上層
+	IDs    []uint `json:"ids" binding:"required,min=1"` // 依新順序排列的分類 ID，必須包含該層所有分類
 }
 
 type CategoryResponse struct {
-	ID       uint                `json:"id"`       // 分類 ID
-	Name     string              `json:"name"`     // 分類名稱
-	Children []*CategoryResponse `json:"children"` // 子分類
+	ID        uint                `json:"id"`        // 分類 ID
+	Name      string              `json:"name"`      // 分類名稱
+	Slug      string              `json:"slug"`      // 分類 slug
+	SortOrder int                 `json:"sortOrder"` // 同層排序
+	Children  []*CategoryResponse `json:"children"`  // 子分類
 }
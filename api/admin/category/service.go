@@ -4,9 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
 	"blog-backend/common/entity"
 	"blog-backend/common/middleware"
+	"blog-backend/common/search"
+	"blog-backend/common/utils"
+	"blog-backend/common/webhook"
 
 	"github.com/uptrace/bun"
 )
@@ -14,6 +19,11 @@ import (
 type CategoryService interface {
 	GetCategoryTree() ([]*CategoryResponse, error)
 	GetCategoryByID(id string) (entity.Category, error)
+	CreateCategory(req CreateCategoryRequest) (entity.Category, error)
+	UpdateCategory(id string, req UpdateCategoryRequest) (entity.Category, error)
+	MoveCategory(id string, req MoveCategoryRequest) (entity.Category, error)
+	ReorderCategories(req ReorderCategoriesRequest) error
+	DeleteCategory(id string) error
 }
 
 type categoryServiceImpl struct {
@@ -28,6 +38,7 @@ func (s *categoryServiceImpl) GetCategoryTree() ([]*CategoryResponse, error) {
 	var categories []entity.Category
 	err := s.db.NewSelect().
 		Model(&categories).
+		Order("sort_order ASC").
 		Scan(context.Background())
 	if err != nil {
 		return nil, middleware.WrapDBErr("查詢分類失敗", err)
@@ -39,9 +50,11 @@ func (s *categoryServiceImpl) GetCategoryTree() ([]*CategoryResponse, error) {
 	// 先建立所有節點
 	for _, cat := range categories {
 		idToNode[cat.ID] = &CategoryResponse{
-			ID:       cat.ID,
-			Name:     cat.Name,
-			Children: []*CategoryResponse{},
+			ID:        cat.ID,
+			Name:      cat.Name,
+			Slug:      cat.Slug,
+			SortOrder: cat.SortOrder,
+			Children:  []*CategoryResponse{},
 		}
 	}
 
@@ -93,3 +106,499 @@ func (s *categoryServiceImpl) GetCategoryByID(id string) (entity.Category, error
 
 	return category, nil
 }
+
+func (s *categoryServiceImpl) CreateCategory(req CreateCategoryRequest) (entity.Category, error) {
+	ctx := context.Background()
+	now := time.Now()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Category{}, middleware.ErrTransaction
+	}
+	defer tx.Rollback()
+
+	// 檢查上層分類是否存在
+	if req.Parent != nil {
+		if _, err := findCategory(ctx, tx, *req.Parent); err != nil {
+			return entity.Category{}, err
+		}
+	}
+
+	slug, err := resolveSlug(ctx, tx, 0, req.Slug, req.Name)
+	if err != nil {
+		return entity.Category{}, err
+	}
+
+	sortOrder, err := nextSortOrder(ctx, tx, req.Parent)
+	if err != nil {
+		return entity.Category{}, err
+	}
+
+	category := entity.Category{
+		Name:        req.Name,
+		Parent:      req.Parent,
+		Slug:        slug,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		HasChildren: false,
+		SortOrder:   sortOrder,
+	}
+	_, err = tx.NewInsert().Model(&category).Returning("id").Exec(ctx)
+	if err != nil {
+		return entity.Category{}, middleware.WrapDBErr("新增分類失敗", err)
+	}
+
+	// 上層分類多了一個子分類
+	if err := refreshHasChildren(ctx, tx, req.Parent); err != nil {
+		return entity.Category{}, err
+	}
+
+	deliveryIDs, err := enqueueCategoryEvent(ctx, tx, webhook.EventCategoryCreated, webhook.NewCategoryData(category))
+	if err != nil {
+		return entity.Category{}, err
+	}
+
+	if err := utils.BumpContentVersion(ctx, tx); err != nil {
+		return entity.Category{}, middleware.WrapDBErr("更新內容版本失敗", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.Category{}, middleware.ErrTransaction
+	}
+
+	purgeCacheAndDeploy("CreateCategory")
+	dispatchWebhooks(s.db, "CreateCategory", deliveryIDs)
+
+	return s.GetCategoryByID(fmt.Sprint(category.ID))
+}
+
+func (s *categoryServiceImpl) UpdateCategory(id string, req UpdateCategoryRequest) (entity.Category, error) {
+	ctx := context.Background()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Category{}, middleware.ErrTransaction
+	}
+	defer tx.Rollback()
+
+	category, err := findCategory(ctx, tx, id)
+	if err != nil {
+		return entity.Category{}, err
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			return entity.Category{}, middleware.Newf(middleware.ErrValidation.Code, "分類名稱不能為空")
+		}
+		category.Name = *req.Name
+	}
+
+	if req.Slug != nil && *req.Slug != category.Slug {
+		slug, err := resolveSlug(ctx, tx, category.ID, *req.Slug, category.Name)
+		if err != nil {
+			return entity.Category{}, err
+		}
+		// 記錄舊 slug，讓舊連結可以轉址
+		if err := utils.RecordSlugChange(ctx, tx, utils.SlugEntityCategory, category.ID, category.Slug, slug); err != nil {
+			return entity.Category{}, middleware.WrapDBErr("記錄舊 slug 失敗", err)
+		}
+		category.Slug = slug
+	}
+
+	category.UpdatedAt = time.Now()
+	_, err = tx.NewUpdate().
+		Model(&category).
+		Column("name", "slug", "updated_at").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return entity.Category{}, middleware.WrapDBErr("更新分類失敗", err)
+	}
+
+	deliveryIDs, err := enqueueCategoryEvent(ctx, tx, webhook.EventCategoryUpdated, webhook.NewCategoryData(category))
+	if err != nil {
+		return entity.Category{}, err
+	}
+
+	if err := utils.BumpContentVersion(ctx, tx); err != nil {
+		return entity.Category{}, middleware.WrapDBErr("更新內容版本失敗", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.Category{}, middleware.ErrTransaction
+	}
+
+	purgeCacheAndDeploy("UpdateCategory")
+	dispatchWebhooks(s.db, "UpdateCategory", deliveryIDs)
+
+	return category, nil
+}
+
+func (s *categoryServiceImpl) MoveCategory(id string, req MoveCategoryRequest) (entity.Category, error) {
+	ctx := context.Background()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Category{}, middleware.ErrTransaction
+	}
+	defer tx.Rollback()
+
+	category, err := findCategory(ctx, tx, id)
+	if err != nil {
+		return entity.Category{}, err
+	}
+
+	if sameParent(category.Parent, req.Parent) {
+		return category, nil
+	}
+
+	// 新的上層分類必須存在，且不能是自己或自己的子孫（避免形成循環）
+	if req.Parent != nil {
+		if _, err := findCategory(ctx, tx, *req.Parent); err != nil {
+			return entity.Category{}, err
+		}
+		descendant, err := isSelfOrDescendant(ctx, tx, category.ID, *req.Parent)
+		if err != nil {
+			return entity.Category{}, err
+		}
+		if descendant {
+			return entity.Category{}, middleware.Newf(middleware.ErrValidation.Code, "無法將分類移到自己或自己的子分類底下")
+		}
+	}
+
+	sortOrder, err := nextSortOrder(ctx, tx, req.Parent)
+	if err != nil {
+		return entity.Category{}, err
+	}
+
+	oldParent := category.Parent
+	category.Parent = req.Parent
+	category.SortOrder = sortOrder
+	category.UpdatedAt = time.Now()
+
+	_, err = tx.NewUpdate().
+		Model(&category).
+		Column("parent", "sort_order", "updated_at").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return entity.Category{}, middleware.WrapDBErr("移動分類失敗", err)
+	}
+
+	// 舊、新上層分類的 has_children 都可能改變
+	if err := refreshHasChildren(ctx, tx, oldParent); err != nil {
+		return entity.Category{}, err
+	}
+	if err := refreshHasChildren(ctx, tx, req.Parent); err != nil {
+		return entity.Category{}, err
+	}
+
+	// 分類樹改變後，文章之間的分類距離都可能不同，相關文章全部重算
+	if _, err := search.RebuildRelatedPosts(ctx, tx); err != nil {
+		return entity.Category{}, middleware.WrapDBErr("更新相關文章失敗", err)
+	}
+
+	deliveryIDs, err := enqueueCategoryEvent(ctx, tx, webhook.EventCategoryMoved, webhook.NewCategoryData(category))
+	if err != nil {
+		return entity.Category{}, err
+	}
+
+	if err := utils.BumpContentVersion(ctx, tx); err != nil {
+		return entity.Category{}, middleware.WrapDBErr("更新內容版本失敗", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.Category{}, middleware.ErrTransaction
+	}
+
+	purgeCacheAndDeploy("MoveCategory")
+	dispatchWebhooks(s.db, "MoveCategory", deliveryIDs)
+
+	return s.GetCategoryByID(fmt.Sprint(category.ID))
+}
+
+func (s *categoryServiceImpl) ReorderCategories(req ReorderCategoriesRequest) error {
+	ctx := context.Background()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return middleware.ErrTransaction
+	}
+	defer tx.Rollback()
+
+	var siblings []entity.Category
+	query := tx.NewSelect().Model(&siblings)
+	if req.Parent == nil {
+		query = query.Where("parent IS NULL")
+	} else {
+		query = query.Where("parent = ?", *req.Parent)
+	}
+	if err := query.Scan(ctx); err != nil {
+		return middleware.WrapDBErr("查詢同層分類失敗", err)
+	}
+
+	// 傳入的 ID 必須剛好是同層的所有分類，不可缺漏或重複
+	siblingSet := make(map[uint]bool)
+	for _, sib := range siblings {
+		siblingSet[sib.ID] = true
+	}
+	if len(req.IDs) != len(siblingSet) {
+		return middleware.Newf(middleware.ErrValidation.Code, "排序清單必須包含同層的全部 %d 個分類", len(siblingSet))
+	}
+	seen := make(map[uint]bool)
+	for _, catID := range req.IDs {
+		if !siblingSet[catID] || seen[catID] {
+			return middleware.Newf(middleware.ErrValidation.Code, "分類 %d 不屬於此層或重複出現", catID)
+		}
+		seen[catID] = true
+	}
+
+	now := time.Now()
+	for i, catID := range req.IDs {
+		_, err := tx.NewUpdate().
+			Model((*entity.Category)(nil)).
+			Set("sort_order = ?", i).
+			Set("updated_at = ?", now).
+			Where("id = ?", catID).
+			Exec(ctx)
+		if err != nil {
+			return middleware.WrapDBErr("更新分類排序失敗", err)
+		}
+	}
+
+	deliveryIDs, err := enqueueCategoryEvent(ctx, tx, webhook.EventCategoryReordered, webhook.CategoryReorderData{
+		Parent: req.Parent,
+		IDs:    req.IDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := utils.BumpContentVersion(ctx, tx); err != nil {
+		return middleware.WrapDBErr("更新內容版本失敗", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return middleware.ErrTransaction
+	}
+
+	purgeCacheAndDeploy("ReorderCategories")
+	dispatchWebhooks(s.db, "ReorderCategories", deliveryIDs)
+
+	return nil
+}
+
+func (s *categoryServiceImpl) DeleteCategory(id string) error {
+	ctx := context.Background()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return middleware.ErrTransaction
+	}
+	defer tx.Rollback()
+
+	category, err := findCategory(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if category.HasChildren {
+		return middleware.Newf(middleware.ErrValidation.Code, "請先刪除或移動「%s」底下的子分類", category.Name)
+	}
+
+	// 軟刪除的文章也算在內，避免日後還原時指向不存在的分類
+	postCount, err := tx.NewSelect().
+		Model((*entity.Post)(nil)).
+		Where("category_id = ?", category.ID).
+		Count(ctx)
+	if err != nil {
+		return middleware.WrapDBErr("查詢分類文章失敗", err)
+	}
+	if postCount > 0 {
+		return middleware.Newf(middleware.ErrValidation.Code, "「%s」底下還有 %d 篇文章，請先移動文章", category.Name, postCount)
+	}
+
+	_, err = tx.NewDelete().
+		Model(&category).
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return middleware.WrapDBErr("刪除分類失敗", err)
+	}
+
+	if err := refreshHasChildren(ctx, tx, category.Parent); err != nil {
+		return err
+	}
+
+	if err := utils.DeleteSlugHistory(ctx, tx, utils.SlugEntityCategory, category.ID); err != nil {
+		return middleware.WrapDBErr("刪除分類 slug 歷史失敗", err)
+	}
+
+	deliveryIDs, err := enqueueCategoryEvent(ctx, tx, webhook.EventCategoryDeleted, webhook.NewCategoryData(category))
+	if err != nil {
+		return err
+	}
+
+	if err := utils.BumpContentVersion(ctx, tx); err != nil {
+		return middleware.WrapDBErr("更新內容版本失敗", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return middleware.ErrTransaction
+	}
+
+	purgeCacheAndDeploy("DeleteCategory")
+	dispatchWebhooks(s.db, "DeleteCategory", deliveryIDs)
+
+	return nil
+}
+
+// 依 ID 查詢分類（可在交易內使用）
+func findCategory(ctx context.Context, db bun.IDB, id interface{}) (entity.Category, error) {
+	var category entity.Category
+	err := db.NewSelect().
+		Model(&category).
+		Where("category.id = ?", id).
+		Limit(1).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Category{}, middleware.Newf(middleware.ErrNotFound.Code, "找不到分類：%v", id)
+	} else if err != nil {
+		return entity.Category{}, middleware.WrapDBErr("查詢分類失敗", err)
+	}
+	return category, nil
+}
+
+// 決定要使用的 slug：有指定就驗證格式與唯一性，沒指定就由名稱產生不重複的 slug
+func resolveSlug(ctx context.Context, db bun.IDB, selfID uint, slug, name string) (string, error) {
+	// 其他分類目前或曾經使用過的 slug 都不能用
+	exists := func(candidate string) (bool, error) {
+		taken, err := utils.IsSlugTaken(ctx, db, utils.SlugEntityCategory, selfID, candidate)
+		if err != nil {
+			return false, middleware.WrapDBErr("檢查 slug 失敗", err)
+		}
+		return taken, nil
+	}
+
+	if slug != "" {
+		if !utils.IsValidSlug(slug) {
+			return "", middleware.Newf(middleware.ErrValidation.Code, "slug 格式錯誤，只能包含小寫英數字與連字號：%s", slug)
+		}
+		taken, err := exists(slug)
+		if err != nil {
+			return "", err
+		}
+		if taken {
+			return "", middleware.Newf(middleware.ErrValidation.Code, "slug 已被使用：%s", slug)
+		}
+		return slug, nil
+	}
+
+	// 中文名稱轉不出 slug 時，給一個預設前綴
+	base := utils.Slugify(name)
+	if base == "" {
+		base = "category"
+	}
+	return utils.UniqueSlug(base, exists)
+}
+
+// 取得某層分類的下一個排序值（排在最後）
+func nextSortOrder(ctx context.Context, db bun.IDB, parent *uint) (int, error) {
+	var maxOrder sql.NullInt64
+	query := db.NewSelect().
+		Model((*entity.Category)(nil)).
+		ColumnExpr("MAX(sort_order)")
+	if parent == nil {
+		query = query.Where("parent IS NULL")
+	} else {
+		query = query.Where("parent = ?", *parent)
+	}
+	if err := query.Scan(ctx, &maxOrder); err != nil {
+		return 0, middleware.WrapDBErr("查詢分類排序失敗", err)
+	}
+	if !maxOrder.Valid {
+		return 0, nil
+	}
+	return int(maxOrder.Int64) + 1, nil
+}
+
+// 依實際子分類數量重新計算 has_children
+func refreshHasChildren(ctx context.Context, db bun.IDB, categoryID *uint) error {
+	if categoryID == nil {
+		return nil
+	}
+	_, err := db.NewUpdate().
+		Model((*entity.Category)(nil)).
+		Set("has_children = EXISTS (SELECT 1 FROM categories AS c WHERE c.parent = ?)", *categoryID).
+		Set("updated_at = NOW()").
+		Where("id = ?", *categoryID).
+		Exec(ctx)
+	if err != nil {
+		return middleware.WrapDBErr("更新分類子節點狀態失敗", err)
+	}
+	return nil
+}
+
+// 檢查 targetID 是否為 categoryID 本身或其子孫
+func isSelfOrDescendant(ctx context.Context, db bun.IDB, categoryID, targetID uint) (bool, error) {
+	var categories []entity.Category
+	if err := db.NewSelect().Model(&categories).Column("id", "parent").Scan(ctx); err != nil {
+		return false, middleware.WrapDBErr("查詢分類失敗", err)
+	}
+
+	parentOf := make(map[uint]*uint)
+	for _, cat := range categories {
+		parentOf[cat.ID] = cat.Parent
+	}
+
+	// 從 target 往上走，途中遇到 categoryID 就代表是子孫
+	visited := make(map[uint]bool)
+	for current := &targetID; current != nil; current = parentOf[*current] {
+		if *current == categoryID {
+			return true, nil
+		}
+		if visited[*current] {
+			break
+		}
+		visited[*current] = true
+	}
+	return false, nil
+}
+
+func sameParent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// ✅ 清除快取 + 重新部署（不影響主流程）
+func purgeCacheAndDeploy(action string) {
+	go func() {
+		if err := utils.PurgeWorkerCacheAndDeployVercel(); err != nil {
+			fmt.Printf("⚠️ 部署失敗（%s）：%v\n", action, err)
+		}
+	}()
+}
+
+// 建立分類事件的 webhook 傳送紀錄（與分類異動在同一個交易中）
+func enqueueCategoryEvent(ctx context.Context, tx bun.Tx, event string, data interface{}) ([]int64, error) {
+	ids, err := webhook.Enqueue(ctx, tx, event, data)
+	if err != nil {
+		return nil, middleware.WrapDBErr("建立 webhook 傳送紀錄失敗", err)
+	}
+	return ids, nil
+}
+
+// ✅ 送出 webhook（不影響主流程，失敗的由 batch 重試）
+func dispatchWebhooks(db bun.IDB, action string, ids []int64) {
+	if len(ids) == 0 {
+		return
+	}
+	go func() {
+		if _, err := webhook.Deliver(context.Background(), db, ids); err != nil {
+			fmt.Printf("⚠️ webhook 傳送失敗（%s）：%v\n", action, err)
+		}
+	}()
+}
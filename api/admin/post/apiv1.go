@@ -1,6 +1,12 @@
 package post
 
 import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
 	"blog-backend/common/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -20,6 +26,7 @@ func (api *PostAPI) RegisterRoutes(r *gin.Engine) {
 	apiGroup := r.Group("/api/post")
 	{
 		apiGroup.GET("", api.GetPostList)
+		apiGroup.GET("/export", api.ExportArchive)
 		apiGroup.GET("/:id", api.GetPostByID)
 		apiGroup.POST("", api.CreatePost)
 		apiGroup.PATCH("/:id", api.UpdatePost)
@@ -28,6 +35,31 @@ func (api *PostAPI) RegisterRoutes(r *gin.Engine) {
 		apiGroup.GET("/upload-url", api.GetPresignedUploadURL)
 		apiGroup.GET("/about", api.GetAboutMe)
 		apiGroup.POST("/about", api.UpdateAboutMe)
+		apiGroup.GET("/trash", api.GetTrashPosts)
+		apiGroup.POST("/trash/:id/restore", api.RestorePost)
+		apiGroup.DELETE("/trash/:id", api.PurgePost)
+		apiGroup.POST("/bulk", api.BulkPosts)
+		apiGroup.POST("/import", api.ImportMarkdown)
+		apiGroup.GET("/tags", api.GetTags)
+		apiGroup.PATCH("/tags/:tagId", api.UpdateTag)
+		apiGroup.POST("/tags/:tagId/merge", api.MergeTags)
+		apiGroup.GET("/:id/revisions", api.GetPostRevisions)
+		apiGroup.GET("/:id/revisions/diff", api.DiffPostRevisions)
+		apiGroup.GET("/:id/revisions/:revisionId", api.GetPostRevision)
+		apiGroup.POST("/:id/revisions/:revisionId/restore", api.RestorePostRevision)
+		apiGroup.GET("/:id/preview-tokens", api.GetPreviewTokens)
+		apiGroup.POST("/:id/preview-tokens", api.CreatePreviewToken)
+		apiGroup.DELETE("/:id/preview-tokens/:tokenId", api.RevokePreviewToken)
+		apiGroup.GET("/comments", api.GetComments)
+		apiGroup.POST("/comments/bulk", api.BulkComments)
+		apiGroup.POST("/comments/:commentId/approve", api.ApproveComment)
+		apiGroup.POST("/comments/:commentId/reject", api.RejectComment)
+		apiGroup.GET("/webhooks", api.GetWebhooks)
+		apiGroup.POST("/webhooks", api.CreateWebhook)
+		apiGroup.GET("/webhooks/deliveries", api.GetWebhookDeliveries)
+		apiGroup.POST("/webhooks/deliveries/:deliveryId/redeliver", api.RedeliverWebhook)
+		apiGroup.PATCH("/webhooks/:webhookId", api.UpdateWebhook)
+		apiGroup.DELETE("/webhooks/:webhookId", api.DeleteWebhook)
 	}
 }
 
@@ -46,7 +78,7 @@ func (api *PostAPI) GetPostList(c *gin.Context) {
 	c.Set("data", result)
 }
 
-// 文章詳情
+// 文章詳情（ETag 為目前的版本號）
 func (api *PostAPI) GetPostByID(c *gin.Context) {
 	id := c.Param("id")
 	post, err := api.service.GetPostByID(id)
@@ -54,6 +86,7 @@ func (api *PostAPI) GetPostByID(c *gin.Context) {
 		c.Error(err)
 		return
 	}
+	setVersionETag(c, post.Version)
 	c.Set("data", post)
 }
 
@@ -70,10 +103,11 @@ func (api *PostAPI) CreatePost(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, result.Version)
 	c.Set("data", result)
 }
 
-// 編輯文章
+// 編輯文章（版本號以 If-Match header 或 version 欄位帶入，過期時回傳 ErrConflict）
 func (api *PostAPI) UpdatePost(c *gin.Context) {
 	id := c.Param("id")
 	var req UpdatePostDto
@@ -81,11 +115,19 @@ func (api *PostAPI) UpdatePost(c *gin.Context) {
 		c.Error(middleware.ErrValidation)
 		return
 	}
+	version, err := requestVersion(c, req.Version)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	req.Version = version
+
 	result, err := api.service.UpdatePost(id, req)
 	if err != nil {
 		c.Error(err)
 		return
 	}
+	setVersionETag(c, result.Version)
 	c.Set("data", result)
 }
 
@@ -126,28 +168,434 @@ func (api *PostAPI) GetPresignedUploadURL(c *gin.Context) {
 	c.Set("data", url)
 }
 
-// 取得關於我內容
+// 取得關於我內容（ETag 為目前的版本號）
 func (api *PostAPI) GetAboutMe(c *gin.Context) {
 	about, err := api.service.GetAboutMe()
 	if err != nil {
 		c.Error(err)
 		return
 	}
+	setVersionETag(c, about.Version)
 	c.Set("data", about)
 }
 
-// 更新關於我內容
+// 更新關於我內容（版本號以 If-Match header 或 version 欄位帶入，過期時回傳 ErrConflict）
 func (api *PostAPI) UpdateAboutMe(c *gin.Context) {
 	var req UpdateAboutMeDto
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.Error(middleware.ErrValidation)
 		return
 	}
+	version, err := requestVersion(c, req.Version)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	req.Version = version
 
 	updated, err := api.service.UpdateAboutMe(req)
 	if err != nil {
 		c.Error(err)
 		return
 	}
+	setVersionETag(c, updated.Version)
 	c.Set("data", updated)
 }
+
+// 文章版本列表
+func (api *PostAPI) GetPostRevisions(c *gin.Context) {
+	id := c.Param("id")
+	revisions, err := api.service.GetPostRevisions(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", revisions)
+}
+
+// 單一版本內容
+func (api *PostAPI) GetPostRevision(c *gin.Context) {
+	id := c.Param("id")
+	revisionID := c.Param("revisionId")
+	revision, err := api.service.GetPostRevision(id, revisionID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", revision)
+}
+
+// 比對兩個版本（?from=&to=）
+func (api *PostAPI) DiffPostRevisions(c *gin.Context) {
+	id := c.Param("id")
+	from := c.Query("from")
+	to := c.Query("to")
+	if from == "" || to == "" {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+	diff, err := api.service.DiffPostRevisions(id, from, to)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", diff)
+}
+
+// 還原成舊版本（會產生一個新版本；版本號以 If-Match header 或 version 欄位帶入，過期時回傳 ErrConflict）
+func (api *PostAPI) RestorePostRevision(c *gin.Context) {
+	id := c.Param("id")
+	revisionID := c.Param("revisionId")
+
+	var req RestorePostRevisionDto
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(middleware.ErrValidation)
+			return
+		}
+	}
+	version, err := requestVersion(c, req.Version)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	req.Version = version
+
+	post, err := api.service.RestorePostRevision(id, revisionID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	setVersionETag(c, post.Version)
+	c.Set("data", post)
+}
+
+// 標籤列表（含文章數）
+func (api *PostAPI) GetTags(c *gin.Context) {
+	tags, err := api.service.GetTags()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", tags)
+}
+
+// 修改標籤名稱或 slug
+func (api *PostAPI) UpdateTag(c *gin.Context) {
+	id := c.Param("tagId")
+	var req UpdateTagDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(middleware.ErrValidation)
+		return
+	}
+	tag, err := api.service.UpdateTag(id, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", tag)
+}
+
+// 合併標籤：把文章移到目標標籤後刪除原標籤
+func (api *PostAPI) MergeTags(c *gin.Context) {
+	id := c.Param("tagId")
+	var req MergeTagDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(middleware.ErrValidation)
+		return
+	}
+	tag, err := api.service.MergeTags(id, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", tag)
+}
+
+// 垃圾桶文章列表
+func (api *PostAPI) GetTrashPosts(c *gin.Context) {
+	var req GetPostListDto
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+	result, err := api.service.GetTrashPosts(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 從垃圾桶還原文章
+func (api *PostAPI) RestorePost(c *gin.Context) {
+	id := c.Param("id")
+	result, err := api.service.RestorePost(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 永久刪除垃圾桶內的文章
+func (api *PostAPI) PurgePost(c *gin.Context) {
+	id := c.Param("id")
+	if err := api.service.PurgePost(id); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// 批次發佈、下架、移動分類、刪除或還原文章
+func (api *PostAPI) BulkPosts(c *gin.Context) {
+	var req BulkPostDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(middleware.ErrValidation)
+		return
+	}
+	result, err := api.service.BulkPosts(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 匯入 Markdown 文章：可以送 JSON（{"markdown": "..."}），或直接以 text/markdown 送原文
+func (api *PostAPI) ImportMarkdown(c *gin.Context) {
+	var req ImportMarkdownDto
+	switch c.ContentType() {
+	case "text/markdown", "text/plain":
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil || len(body) == 0 {
+			c.Error(middleware.ErrValidation)
+			return
+		}
+		req.Markdown = string(body)
+	default:
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(middleware.ErrValidation)
+			return
+		}
+	}
+
+	post, err := api.service.ImportMarkdown(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", post)
+}
+
+// 匯出整個部落格（zip 串流下載）
+func (api *PostAPI) ExportArchive(c *gin.Context) {
+	filename := fmt.Sprintf("blog-export-%s.zip", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	if err := api.service.ExportArchive(c.Writer); err != nil {
+		// 已經開始傳送 zip 時無法再改成錯誤回應，只能中斷連線
+		if c.Writer.Written() {
+			fmt.Printf("❌ 匯出中斷：%v\n", err)
+			c.Abort()
+			return
+		}
+		c.Writer.Header().Del("Content-Disposition")
+		c.Error(middleware.WrapDBErr("匯出失敗", err))
+	}
+}
+
+// 預覽連結列表
+func (api *PostAPI) GetPreviewTokens(c *gin.Context) {
+	id := c.Param("id")
+	tokens, err := api.service.GetPreviewTokens(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", tokens)
+}
+
+// 產生草稿預覽連結的 token
+func (api *PostAPI) CreatePreviewToken(c *gin.Context) {
+	id := c.Param("id")
+	var req CreatePreviewTokenDto
+	// body 可以省略，全部使用預設值
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(middleware.ErrValidation)
+			return
+		}
+	}
+	token, err := api.service.CreatePreviewToken(id, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", token)
+}
+
+// 撤銷預覽連結
+func (api *PostAPI) RevokePreviewToken(c *gin.Context) {
+	id := c.Param("id")
+	tokenID := c.Param("tokenId")
+	if err := api.service.RevokePreviewToken(id, tokenID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// 留言列表（預設為待審核的留言）
+func (api *PostAPI) GetComments(c *gin.Context) {
+	var req GetCommentListDto
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+	result, err := api.service.GetComments(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 核准留言
+func (api *PostAPI) ApproveComment(c *gin.Context) {
+	id := c.Param("commentId")
+	comment, err := api.service.ApproveComment(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", comment)
+}
+
+// 退回留言
+func (api *PostAPI) RejectComment(c *gin.Context) {
+	id := c.Param("commentId")
+	comment, err := api.service.RejectComment(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", comment)
+}
+
+// 批次核准、退回或標記垃圾留言
+func (api *PostAPI) BulkComments(c *gin.Context) {
+	var req BulkCommentDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(middleware.ErrValidation)
+		return
+	}
+	result, err := api.service.BulkComments(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// webhook 列表
+func (api *PostAPI) GetWebhooks(c *gin.Context) {
+	result, err := api.service.GetWebhooks()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 新增 webhook（回傳的 secret 用來驗證簽章）
+func (api *PostAPI) CreateWebhook(c *gin.Context) {
+	var req CreateWebhookDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(middleware.ErrValidation)
+		return
+	}
+	result, err := api.service.CreateWebhook(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 修改 webhook 的名稱、網址、事件、啟用狀態，或重新產生金鑰
+func (api *PostAPI) UpdateWebhook(c *gin.Context) {
+	id := c.Param("webhookId")
+	var req UpdateWebhookDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(middleware.ErrValidation)
+		return
+	}
+	result, err := api.service.UpdateWebhook(id, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 刪除 webhook
+func (api *PostAPI) DeleteWebhook(c *gin.Context) {
+	id := c.Param("webhookId")
+	if err := api.service.DeleteWebhook(id); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", nil)
+}
+
+// webhook 傳送紀錄
+func (api *PostAPI) GetWebhookDeliveries(c *gin.Context) {
+	var req GetWebhookDeliveryListDto
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+	result, err := api.service.GetWebhookDeliveries(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 手動重送一筆 webhook
+func (api *PostAPI) RedeliverWebhook(c *gin.Context) {
+	id := c.Param("deliveryId")
+	result, err := api.service.RedeliverWebhook(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 以版本號當作 ETag，例如 "3"
+func setVersionETag(c *gin.Context, version int) {
+	c.Header("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// 取得更新時帶入的版本號：If-Match header 優先，沒有時使用 body 的 version，兩者都有時必須相同
+func requestVersion(c *gin.Context, bodyVersion *int) (*int, error) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" {
+		return bodyVersion, nil
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`)
+	version, err := strconv.Atoi(tag)
+	if err != nil {
+		return nil, middleware.Newf(middleware.ErrBadRequest.Code, "If-Match 格式錯誤，請帶入取得資料時的 ETag：%s", ifMatch)
+	}
+	if bodyVersion != nil && *bodyVersion != version {
+		return nil, middleware.Newf(middleware.ErrBadRequest.Code, "If-Match（%d）與 version 欄位（%d）不一致", version, *bodyVersion)
+	}
+	return &version, nil
+}
@@ -0,0 +1,256 @@
+package post
+
+import (
+	"context"
+	"time"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/middleware"
+	"blog-backend/common/newsletter"
+	"blog-backend/common/search"
+	"blog-backend/common/utils"
+	"blog-backend/common/webhook"
+
+	"github.com/uptrace/bun"
+)
+
+// 批次操作種類
+const (
+	bulkActionPublish   = "publish"
+	bulkActionUnpublish = "unpublish"
+	bulkActionMove      = "move"
+	bulkActionDelete    = "delete"
+	bulkActionRestore   = "restore"
+)
+
+// 所有文章在同一個交易中處理，找不到或狀態不符的文章會記錄在結果中並略過；
+// 資料庫錯誤則整批回滾。成功後最多只觸發一次清除快取 + 重新部署
+func (s *postServiceImpl) BulkPosts(req BulkPostDto) (BulkPostResultDto, error) {
+	ctx := context.Background()
+
+	ids := []uint{}
+	seen := make(map[uint]bool)
+	for _, id := range req.IDs {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return BulkPostResultDto{}, middleware.Newf(middleware.ErrValidation.Code, "請至少選擇一篇文章")
+	}
+
+	if req.Action == bulkActionMove {
+		if req.CategoryID == nil {
+			return BulkPostResultDto{}, middleware.Newf(middleware.ErrValidation.Code, "移動分類時必須指定 categoryId")
+		}
+		exists, err := s.db.NewSelect().
+			Model((*entity.Category)(nil)).
+			Where("id = ?", *req.CategoryID).
+			Exists(ctx)
+		if err != nil {
+			return BulkPostResultDto{}, middleware.ErrDB
+		}
+		if !exists {
+			return BulkPostResultDto{}, middleware.Newf(middleware.ErrNotFound.Code, "找不到分類：%d", *req.CategoryID)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return BulkPostResultDto{}, middleware.ErrTransaction
+	}
+	defer tx.Rollback()
+
+	var posts []entity.Post
+	err = tx.NewSelect().
+		Model(&posts).
+		Where("id IN (?)", bun.In(ids)).
+		For("UPDATE").
+		Scan(ctx)
+	if err != nil {
+		return BulkPostResultDto{}, middleware.ErrDB
+	}
+	postMap := make(map[uint]entity.Post, len(posts))
+	for _, post := range posts {
+		postMap[post.ID] = post
+	}
+
+	result := BulkPostResultDto{Action: req.Action, Results: []BulkPostItemDto{}}
+	needsDeploy := false
+	notifyIDs := []uint{}
+	deliveryIDs := []int64{}
+
+	for _, id := range ids {
+		item := BulkPostItemDto{ID: id}
+
+		post, ok := postMap[id]
+		if !ok {
+			item.Error = "找不到文章"
+			result.Results = append(result.Results, item)
+			continue
+		}
+		if req.Action == bulkActionRestore && !post.IsDeleted {
+			item.Error = "文章不在垃圾桶中"
+			result.Results = append(result.Results, item)
+			continue
+		}
+		if req.Action != bulkActionRestore && post.IsDeleted {
+			item.Error = "文章已在垃圾桶中"
+			result.Results = append(result.Results, item)
+			continue
+		}
+
+		switch req.Action {
+		case bulkActionPublish, bulkActionUnpublish, bulkActionMove:
+			if err := bulkUpdatePost(ctx, tx, post, req); err != nil {
+				return BulkPostResultDto{}, err
+			}
+			// 從未發佈變成發佈：通知電子報訂閱者
+			if req.Action == bulkActionPublish && !post.IsPublished {
+				claimed, err := newsletter.ClaimPostNotification(ctx, tx, post.ID)
+				if err != nil {
+					return BulkPostResultDto{}, middleware.WrapDBErr("建立電子報通知失敗", err)
+				}
+				if claimed {
+					notifyIDs = append(notifyIDs, post.ID)
+				}
+			}
+		case bulkActionDelete:
+			if err := softDeletePostTx(ctx, tx, post.ID); err != nil {
+				return BulkPostResultDto{}, err
+			}
+		case bulkActionRestore:
+			_, missing, err := restorePostTx(ctx, tx, post.ID)
+			if err != nil {
+				return BulkPostResultDto{}, err
+			}
+			item.MissingImages = missing
+		}
+
+		events := []string{webhook.EventPostUpdated}
+		switch {
+		case req.Action == bulkActionPublish && !post.IsPublished:
+			events = append(events, webhook.EventPostPublished)
+		case req.Action == bulkActionDelete:
+			events = []string{webhook.EventPostDeleted}
+		}
+		created, err := enqueuePostEvents(ctx, tx, post.ID, events...)
+		if err != nil {
+			return BulkPostResultDto{}, err
+		}
+		deliveryIDs = append(deliveryIDs, created...)
+
+		// 原本已發佈、或這次被發佈的文章才會影響前台
+		if post.IsPublished || req.Action == bulkActionPublish {
+			needsDeploy = true
+		}
+
+		item.Success = true
+		result.SucceededCount++
+		result.Results = append(result.Results, item)
+	}
+
+	if result.SucceededCount > 0 {
+		if err := utils.BumpContentVersion(ctx, tx); err != nil {
+			return BulkPostResultDto{}, middleware.WrapDBErr("更新內容版本失敗", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return BulkPostResultDto{}, middleware.ErrTransaction
+	}
+
+	if len(notifyIDs) > 0 {
+		s.notifySubscribers("BulkPosts", notifyIDs...)
+	}
+	dispatchWebhooks(s.db, "BulkPosts", deliveryIDs)
+	if needsDeploy {
+		purgeCacheAndDeploy("BulkPosts")
+	}
+
+	return result, nil
+}
+
+// 發佈、下架、移動分類會改變文章欄位，跟單篇更新一樣留下版本紀錄
+func bulkUpdatePost(ctx context.Context, tx bun.Tx, post entity.Post, req BulkPostDto) error {
+	if err := ensureInitialRevision(ctx, tx, post); err != nil {
+		return err
+	}
+
+	switch req.Action {
+	case bulkActionPublish:
+		// 立即發佈，原本的排程發佈時間就不需要了
+		post.IsPublished = true
+		post.PublishAt = nil
+	case bulkActionUnpublish:
+		// 手動下架後不應再被排程自動發佈
+		post.IsPublished = false
+		post.PublishAt = nil
+		post.UnpublishAt = nil
+	case bulkActionMove:
+		post.CategoryID = *req.CategoryID
+	}
+	post.NeedsRefresh = true
+	post.UpdatedAt = time.Now()
+	// 批次操作也算一次修改，正在編輯這篇文章的分頁存檔時會收到 ErrConflict
+	post.Version++
+
+	_, err := tx.NewUpdate().
+		Model(&post).
+		Column("is_published", "publish_at", "unpublish_at", "category_id", "needs_refresh", "updated_at", "version").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return middleware.WrapDBErr("批次更新文章失敗", err)
+	}
+
+	if err := insertRevision(ctx, tx, post, revisionSourceBulk, nil); err != nil {
+		return err
+	}
+
+	// 換分類會影響分類距離的分數
+	if req.Action == bulkActionMove {
+		if err := search.RefreshRelatedPosts(ctx, tx, post.ID); err != nil {
+			return middleware.WrapDBErr("更新相關文章失敗", err)
+		}
+	}
+	return nil
+}
+
+// 在交易中把文章移到垃圾桶，圖片改為 pending_delete、並移除搜尋索引與相關文章
+// 文章不存在或已經在垃圾桶中時回傳 ErrNotFound（不重設 deleted_at，保留期限照原本的時間計算）
+func softDeletePostTx(ctx context.Context, tx bun.Tx, id interface{}) error {
+	// 軟刪除：將 is_deleted 設為 true
+	res, err := tx.NewUpdate().
+		Model((*entity.Post)(nil)).
+		Set("is_deleted = true, deleted_at = NOW(), updated_at = NOW(), version = version + 1").
+		Where("id = ?", id).
+		Where("is_deleted = FALSE").
+		Exec(ctx)
+	if err != nil {
+		return middleware.ErrDB
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return middleware.ErrNotFound
+	}
+
+	// 將 images.status 改為 pending_delete
+	_, err = tx.NewUpdate().
+		Model((*entity.Image)(nil)).
+		Set("status = 'pending_delete', updated_at = NOW()").
+		Where("post_id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return middleware.ErrDB
+	}
+
+	if err := search.RemovePost(ctx, tx, id); err != nil {
+		return middleware.WrapDBErr("移除搜尋索引失敗", err)
+	}
+	if err := search.RemoveRelatedPosts(ctx, tx, id); err != nil {
+		return middleware.WrapDBErr("移除相關文章失敗", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"blog-backend/api/admin/post"
+	"blog-backend/common/config"
+	"fmt"
+	"log"
+	"os"
+)
+
+// 用法：go run ./api/admin/post/cmd/import draft1.md draft2.md ...
+// 每個檔案各自建立一篇文章，任何一篇失敗時以非 0 結束
+func main() {
+	files := os.Args[1:]
+	if len(files) == 0 {
+		log.Fatal("請指定要匯入的 Markdown 檔案")
+	}
+
+	db := config.InitDB()
+	service := post.NewPostService(db.DB)
+
+	failed := 0
+	for _, file := range files {
+		markdown, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Printf("❌ 讀取失敗：%s（%v）\n", file, err)
+			failed++
+			continue
+		}
+
+		created, err := service.ImportMarkdown(post.ImportMarkdownDto{Markdown: string(markdown)})
+		if err != nil {
+			fmt.Printf("❌ 匯入失敗：%s（%v）\n", file, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ 已匯入：%s → #%d %s（/%s）\n", file, created.ID, created.Title, created.Slug)
+	}
+
+	// 發佈的文章要等清除快取、部署、webhook 與電子報送出後才能結束
+	post.WaitBackgroundTasks()
+
+	fmt.Printf("📦 匯入完成：成功 %d 篇，失敗 %d 篇\n", len(files)-failed, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,208 @@
+package post
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/middleware"
+	"blog-backend/common/model"
+
+	"github.com/uptrace/bun"
+)
+
+// 留言審核操作對應的狀態
+var commentActionStatus = map[string]string{
+	"approve": entity.CommentStatusApproved,
+	"reject":  entity.CommentStatusDeleted,
+	"spam":    entity.CommentStatusSpam,
+}
+
+// 留言與所屬文章的標題、slug
+type commentWithPost struct {
+	entity.Comment `bun:",extend"`
+
+	PostTitle string `bun:"post_title"`
+	PostSlug  string `bun:"post_slug"`
+}
+
+// 留言列表，預設為審核佇列（pending，最早送出的在前）；其他狀態最新的在前
+func (s *postServiceImpl) GetComments(req GetCommentListDto) (model.PaginatedResponse[CommentDto], error) {
+	ctx := context.Background()
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 15
+	}
+	if req.Status == "" {
+		req.Status = entity.CommentStatusPending
+	}
+
+	filter := func(q *bun.SelectQuery) *bun.SelectQuery {
+		q = q.Where("comment.status = ?", req.Status)
+		if req.PostID != 0 {
+			q = q.Where("comment.post_id = ?", req.PostID)
+		}
+		return q
+	}
+
+	total, err := s.db.NewSelect().
+		Model((*entity.Comment)(nil)).
+		Apply(filter).
+		Count(ctx)
+	if err != nil {
+		return model.PaginatedResponse[CommentDto]{}, middleware.ErrDB
+	}
+
+	order := "comment.created_at DESC, comment.id DESC"
+	if req.Status == entity.CommentStatusPending {
+		order = "comment.created_at ASC, comment.id ASC"
+	}
+
+	var rows []commentWithPost
+	err = s.db.NewSelect().
+		Model(&rows).
+		ColumnExpr("comment.*").
+		ColumnExpr("post.title AS post_title, post.slug AS post_slug").
+		Join("LEFT JOIN posts AS post ON post.id = comment.post_id").
+		Apply(filter).
+		OrderExpr(order).
+		Limit(req.Limit).
+		Offset((req.Page - 1) * req.Limit).
+		Scan(ctx)
+	if err != nil {
+		return model.PaginatedResponse[CommentDto]{}, middleware.ErrDB
+	}
+
+	result := []CommentDto{}
+	for _, row := range rows {
+		result = append(result, toCommentDto(row))
+	}
+
+	return model.PaginatedResponse[CommentDto]{
+		Page:       req.Page,
+		Limit:      req.Limit,
+		TotalCount: total,
+		Data:       result,
+	}, nil
+}
+
+// 核准留言，前台才會顯示
+func (s *postServiceImpl) ApproveComment(id string) (CommentDto, error) {
+	return s.moderateComment(id, "approve")
+}
+
+// 退回留言（狀態改為 deleted）
+func (s *postServiceImpl) RejectComment(id string) (CommentDto, error) {
+	return s.moderateComment(id, "reject")
+}
+
+func (s *postServiceImpl) moderateComment(id string, action string) (CommentDto, error) {
+	ctx := context.Background()
+
+	commentID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return CommentDto{}, middleware.ErrBadRequest
+	}
+
+	updated, err := updateCommentStatus(ctx, s.db, []uint{uint(commentID)}, commentActionStatus[action])
+	if err != nil {
+		return CommentDto{}, err
+	}
+	if len(updated) == 0 {
+		return CommentDto{}, middleware.ErrNotFound
+	}
+
+	var row commentWithPost
+	err = s.db.NewSelect().
+		Model(&row).
+		ColumnExpr("comment.*").
+		ColumnExpr("post.title AS post_title, post.slug AS post_slug").
+		Join("LEFT JOIN posts AS post ON post.id = comment.post_id").
+		Where("comment.id = ?", commentID).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CommentDto{}, middleware.ErrNotFound
+	} else if err != nil {
+		return CommentDto{}, middleware.ErrDB
+	}
+	return toCommentDto(row), nil
+}
+
+// 批次審核留言，找不到的留言記錄在結果中並略過
+func (s *postServiceImpl) BulkComments(req BulkCommentDto) (BulkCommentResultDto, error) {
+	ctx := context.Background()
+
+	ids := []uint{}
+	seen := make(map[uint]bool)
+	for _, id := range req.IDs {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return BulkCommentResultDto{}, middleware.Newf(middleware.ErrValidation.Code, "請至少選擇一則留言")
+	}
+
+	updated, err := updateCommentStatus(ctx, s.db, ids, commentActionStatus[req.Action])
+	if err != nil {
+		return BulkCommentResultDto{}, err
+	}
+	updatedSet := make(map[uint]bool, len(updated))
+	for _, id := range updated {
+		updatedSet[id] = true
+	}
+
+	result := BulkCommentResultDto{Action: req.Action, Results: []BulkCommentItemDto{}}
+	for _, id := range ids {
+		item := BulkCommentItemDto{ID: id, Success: updatedSet[id]}
+		if item.Success {
+			result.SucceededCount++
+		} else {
+			item.Error = "找不到留言"
+		}
+		result.Results = append(result.Results, item)
+	}
+	return result, nil
+}
+
+// 更新留言狀態，回傳實際更新的留言 ID
+func updateCommentStatus(ctx context.Context, db bun.IDB, ids []uint, status string) ([]uint, error) {
+	updated := []uint{}
+	err := db.NewUpdate().
+		Model((*entity.Comment)(nil)).
+		Set("status = ?", status).
+		Set("updated_at = NOW()").
+		Where("id IN (?)", bun.In(ids)).
+		Returning("id").
+		Scan(ctx, &updated)
+	if err != nil {
+		return nil, middleware.WrapDBErr("更新留言狀態失敗", err)
+	}
+	return updated, nil
+}
+
+func toCommentDto(row commentWithPost) CommentDto {
+	return CommentDto{
+		ID:          row.ID,
+		PostID:      row.PostID,
+		PostTitle:   row.PostTitle,
+		PostSlug:    row.PostSlug,
+		ParentID:    row.ParentID,
+		AuthorName:  row.AuthorName,
+		AuthorEmail: row.AuthorEmail,
+		Content:     row.Content,
+		Status:      row.Status,
+		SpamScore:   row.SpamScore,
+		UserAgent:   row.UserAgent,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
@@ -1,6 +1,9 @@
 package post
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type GetPostListDto struct {
 	Page   int    `form:"page"`
@@ -18,27 +21,48 @@ type PostListDto struct {
 }
 
 type PostDto struct {
-	ID            uint      `json:"id"`
-	Title         string    `json:"title"`
-	Content       string    `json:"content"`
-	Summary       string    `json:"summary"`
-	CoverImageUrl string    `json:"coverImageUrl"`
-	IsPublished   bool      `json:"isPublished"`
-	CategoryID    uint      `json:"categoryId"`
-	CreatedAt     time.Time `json:"createdAt"`
-	UpdatedAt     time.Time `json:"updatedAt"`
-	Slug          string    `json:"slug"`
+	ID            uint       `json:"id"`
+	Title         string     `json:"title"`
+	Content       string     `json:"content"`
+	Summary       string     `json:"summary"`
+	Excerpt       string     `json:"excerpt"` // 手寫摘要，空字串表示使用自動產生的摘要
+	CoverImageUrl string     `json:"coverImageUrl"`
+	IsPublished   bool       `json:"isPublished"`
+	CategoryID    uint       `json:"categoryId"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	Slug          string     `json:"slug"`
+	PublishAt     *time.Time `json:"publishAt"`
+	UnpublishAt   *time.Time `json:"unpublishAt"`
+	Tags          []TagDto   `json:"tags"`
+	Version       int        `json:"version"` // 目前的版本號，與 ETag 相同
+	SeoFieldsDto
 }
 
 type UpdatePostDto = CreatePostDto
 
 type CreatePostDto struct {
-	Title         string `json:"title" binding:"required"`
-	CoverImageUrl string `json:"coverImageUrl"`
-	Content       string `json:"content" binding:"required"`
-	CategoryID    uint   `json:"categoryId"`
-	IsPublished   bool   `json:"isPublished"`
-	Slug          string `json:"slug" binding:"required"`
+	Title         string     `json:"title" binding:"required"`
+	CoverImageUrl string     `json:"coverImageUrl"`
+	Content       string     `json:"content" binding:"required"`
+	CategoryID    uint       `json:"categoryId"`
+	IsPublished   bool       `json:"isPublished"`
+	Slug          string     `json:"slug" binding:"required"`
+	PublishAt     *time.Time `json:"publishAt"`   // 可選，排程發佈時間
+	UnpublishAt   *time.Time `json:"unpublishAt"` // 可選，排程下架時間
+	Excerpt       string     `json:"excerpt"`     // 可選，手寫摘要，留空則由內容自動產生
+	Tags          []string   `json:"tags"`        // 標籤 slug，不存在的標籤會自動建立；更新時不帶則維持原本的標籤
+	Version       *int       `json:"version"`     // 更新時必填（或改用 If-Match header），為取得文章時的版本號
+	SeoFieldsDto
+}
+
+// SeoFieldsDto 文章的 SEO 設定，全部可省略，空白時前台使用預設值（見 common/seo）
+type SeoFieldsDto struct {
+	MetaDescription string `json:"metaDescription" binding:"max=300"` // 預設使用摘要
+	SocialTitle     string `json:"socialTitle" binding:"max=200"`     // 社群分享標題，預設使用文章標題
+	OgImageUrl      string `json:"ogImageUrl"`                        // 社群分享圖片，預設使用封面
+	CanonicalUrl    string `json:"canonicalUrl"`                      // 預設為前台文章網址（SITE_BASE_URL）
+	NoIndex         bool   `json:"noIndex"`                           // 不讓搜尋引擎索引
 }
 
 type UploadUrlDto struct {
@@ -50,10 +74,213 @@ type UploadUrlDto struct {
 
 type UpdateAboutMeDto struct {
 	Content string `json:"content" binding:"required"`
+	Version *int   `json:"version"` // 必填（或改用 If-Match header），為取得關於我時的版本號
 }
 
 type AboutMeDto struct {
 	ID        uint      `json:"id"`
 	Content   string    `json:"content"`
 	UpdatedAt time.Time `json:"updatedAt"`
+	Version   int       `json:"version"` // 目前的版本號，與 ETag 相同；尚未建立時為 0
+}
+
+type PostRevisionDto struct {
+	ID            uint      `json:"id"`
+	PostID        uint      `json:"postId"`
+	Title         string    `json:"title"`
+	Slug          string    `json:"slug"`
+	CategoryID    uint      `json:"categoryId"`
+	IsPublished   bool      `json:"isPublished"`
+	CoverImageUrl string    `json:"coverImageUrl"`
+	Source        string    `json:"source"`
+	RestoredFrom  *uint     `json:"restoredFrom"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type PostRevisionDetailDto struct {
+	PostRevisionDto
+	Content string `json:"content"`
+}
+
+type RestorePostRevisionDto struct {
+	Version *int `json:"version"` // 必填（或改用 If-Match header），為取得文章時的版本號
+}
+
+type RevisionDiffDto struct {
+	From   PostRevisionDto `json:"from"`
+	To     PostRevisionDto `json:"to"`
+	Fields []FieldDiffDto  `json:"fields"`
+	Blocks []BlockDiffDto  `json:"blocks"`
+}
+
+type FieldDiffDto struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+type BlockDiffDto struct {
+	Op       string          `json:"op"` // 'unchanged'、'modified'、'added' 或 'removed'
+	Type     string          `json:"type"`
+	OldIndex *int            `json:"oldIndex"`
+	NewIndex *int            `json:"newIndex"`
+	Old      json.RawMessage `json:"old,omitempty"`
+	New      json.RawMessage `json:"new,omitempty"`
+}
+
+type TagDto struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	PostCount int    `json:"postCount,omitempty"`
+}
+
+type UpdateTagDto struct {
+	Name string `json:"name" binding:"required"`
+	Slug string `json:"slug"` // 可選，未填則不變
+}
+
+type MergeTagDto struct {
+	TargetID uint `json:"targetId" binding:"required"` // 合併到哪一個標籤
+}
+
+type TrashPostDto struct {
+	ID            uint       `json:"id"`
+	Title         string     `json:"title"`
+	Slug          string     `json:"slug"`
+	CategoryID    uint       `json:"categoryId"`
+	IsPublished   bool       `json:"isPublished"`
+	CoverImageUrl string     `json:"coverImageUrl"`
+	DeletedAt     *time.Time `json:"deletedAt"`
+	PurgeAt       *time.Time `json:"purgeAt"` // 預計被 batch 永久刪除的時間
+}
+
+type RestorePostResultDto struct {
+	Post          PostDto  `json:"post"`
+	MissingImages []string `json:"missingImages"` // 已被清除、無法還原的圖片
+}
+
+type BulkPostDto struct {
+	IDs        []uint `json:"ids" binding:"required,min=1,max=100"`                                  // 要處理的文章 ID
+	Action     string `json:"action" binding:"required,oneof=publish unpublish move delete restore"` // 批次操作種類
+	CategoryID *uint  `json:"categoryId"`                                                            // action 為 move 時必填
+}
+
+type BulkPostItemDto struct {
+	ID            uint     `json:"id"`
+	Success       bool     `json:"success"`
+	Error         string   `json:"error,omitempty"`         // 失敗原因
+	MissingImages []string `json:"missingImages,omitempty"` // restore 時已被清除、無法還原的圖片
+}
+
+type BulkPostResultDto struct {
+	Action         string            `json:"action"`
+	SucceededCount int               `json:"succeededCount"`
+	Results        []BulkPostItemDto `json:"results"`
+}
+
+type ImportMarkdownDto struct {
+	Markdown string `json:"markdown" binding:"required"` // 含 YAML front matter 的 Markdown 原文
+}
+
+type CreatePreviewTokenDto struct {
+	ExpiresInHours int `json:"expiresInHours" binding:"omitempty,min=1,max=720"` // 有效時數，預設 72 小時
+}
+
+type PreviewTokenDto struct {
+	ID        string     `json:"id"`
+	PostID    uint       `json:"postId"`
+	Token     string     `json:"token,omitempty"` // 只有建立時回傳，之後無法再取得
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt"`
+	Active    bool       `json:"active"` // 未過期且未撤銷
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type GetCommentListDto struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending approved spam deleted"` // 預設 pending（審核佇列）
+	PostID uint   `form:"postId"`                                                         // 只看某篇文章的留言
+	Page   int    `form:"page"`
+	Limit  int    `form:"limit"`
+}
+
+type CommentDto struct {
+	ID          uint      `json:"id"`
+	PostID      uint      `json:"postId"`
+	PostTitle   string    `json:"postTitle"`
+	PostSlug    string    `json:"postSlug"`
+	ParentID    *uint     `json:"parentId"` // 回覆的留言 ID
+	AuthorName  string    `json:"authorName"`
+	AuthorEmail string    `json:"authorEmail"`
+	Content     string    `json:"content"`
+	Status      string    `json:"status"`    // pending、approved、spam 或 deleted
+	SpamScore   float64   `json:"spamScore"` // 垃圾留言分數（0 ~ 1）
+	UserAgent   string    `json:"userAgent"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type BulkCommentDto struct {
+	IDs    []uint `json:"ids" binding:"required,min=1,max=100"`                // 要處理的留言 ID
+	Action string `json:"action" binding:"required,oneof=approve reject spam"` // 核准、退回或標記為垃圾留言
+}
+
+type BulkCommentItemDto struct {
+	ID      uint   `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"` // 失敗原因
+}
+
+type BulkCommentResultDto struct {
+	Action         string               `json:"action"`
+	SucceededCount int                  `json:"succeededCount"`
+	Results        []BulkCommentItemDto `json:"results"`
+}
+
+type CreateWebhookDto struct {
+	Name   string   `json:"name" binding:"required"`                       // 顯示名稱
+	URL    string   `json:"url" binding:"required,url"`                    // 接收事件的網址（http 或 https）
+	Events []string `json:"events" binding:"required,min=1,dive,required"` // 訂閱的事件，例如 post.published、category.*、*
+}
+
+type UpdateWebhookDto struct {
+	Name         *string  `json:"name"`         // 未填則不變
+	URL          *string  `json:"url"`          // 未填則不變
+	Events       []string `json:"events"`       // 未填（null）則不變
+	IsActive     *bool    `json:"isActive"`     // 停用後不會再產生新的傳送
+	RotateSecret bool     `json:"rotateSecret"` // 重新產生簽章金鑰
+}
+
+type WebhookDto struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"` // 接收端驗證 X-Webhook-Signature 用
+	Events    []string  `json:"events"`
+	IsActive  bool      `json:"isActive"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type GetWebhookDeliveryListDto struct {
+	WebhookID uint   `form:"webhookId"`                                                 // 只看某個 webhook 的紀錄
+	Status    string `form:"status" binding:"omitempty,oneof=pending succeeded failed"` // 依狀態篩選
+	Event     string `form:"event"`                                                     // 依事件名稱篩選
+	Page      int    `form:"page"`
+	Limit     int    `form:"limit"`
+}
+
+type WebhookDeliveryDto struct {
+	ID             int64           `json:"id"`
+	WebhookID      uint            `json:"webhookId"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`         // pending、succeeded 或 failed
+	Attempts       int             `json:"attempts"`       // 已嘗試次數
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt"`  // 下次重試時間
+	ResponseStatus int             `json:"responseStatus"` // 最後一次的 HTTP 狀態碼，連線失敗為 0
+	LastError      string          `json:"lastError"`
+	DeliveredAt    *time.Time      `json:"deliveredAt"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	UpdatedAt      time.Time       `json:"updatedAt"`
 }
@@ -0,0 +1,23 @@
+package post
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"blog-backend/common/export"
+)
+
+// 把整個部落格匯出成 zip（Markdown + front matter、關於我、圖片清單）寫到 w
+func (s *postServiceImpl) ExportArchive(w io.Writer) error {
+	summary, err := export.WriteArchive(context.Background(), s.db, w)
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range summary.Warnings {
+		fmt.Printf("⚠️ 匯出警告：%s\n", warning)
+	}
+	fmt.Printf("📦 匯出完成：文章 %d 篇、圖片 %d 張\n", summary.Posts, summary.Images)
+	return nil
+}
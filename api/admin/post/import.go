@@ -0,0 +1,142 @@
+package post
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/middleware"
+	"blog-backend/common/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Markdown 匯入時支援的 front matter 欄位
+type markdownFrontMatter struct {
+	Title     string   `yaml:"title"`
+	Slug      string   `yaml:"slug"`
+	Category  string   `yaml:"category"` // 分類 slug
+	Tags      []string `yaml:"tags"`     // 標籤 slug
+	Cover     string   `yaml:"cover"`    // 封面圖片網址
+	Excerpt   string   `yaml:"excerpt"`  // 手寫摘要
+	Published bool     `yaml:"published"`
+
+	// SEO 設定（可省略）
+	MetaDescription string `yaml:"metaDescription"`
+	SocialTitle     string `yaml:"socialTitle"`
+	OgImage         string `yaml:"ogImage"`
+	CanonicalUrl    string `yaml:"canonicalUrl"`
+	NoIndex         bool   `yaml:"noindex"`
+}
+
+// regex: 內文第一行的一級標題（front matter 沒有 title 時當作標題）
+var markdownTitleRegex = regexp.MustCompile(`^#\s+(.+?)\s*#*\s*$`)
+
+// 把 Markdown（含 YAML front matter）轉成 Editor.js 後，走一般的 CreatePost 流程建立文章
+func (s *postServiceImpl) ImportMarkdown(req ImportMarkdownDto) (PostDto, error) {
+	ctx := context.Background()
+
+	rawFrontMatter, body := utils.SplitFrontMatter(req.Markdown)
+
+	var fm markdownFrontMatter
+	if len(rawFrontMatter) > 0 {
+		if err := yaml.Unmarshal(rawFrontMatter, &fm); err != nil {
+			return PostDto{}, middleware.Newf(middleware.ErrValidation.Code, "front matter 格式錯誤：%v", err)
+		}
+	}
+
+	title := strings.TrimSpace(fm.Title)
+	if title == "" {
+		title, body = takeMarkdownTitle(body)
+	}
+	if title == "" {
+		return PostDto{}, middleware.Newf(middleware.ErrValidation.Code, "缺少標題，請在 front matter 填寫 title 或以一級標題開頭")
+	}
+
+	slug := strings.TrimSpace(fm.Slug)
+	if slug == "" {
+		slug = utils.Slugify(title)
+	}
+	if !utils.IsValidSlug(slug) {
+		return PostDto{}, middleware.Newf(middleware.ErrValidation.Code, "slug 格式錯誤，請在 front matter 填寫只包含小寫英數字與連字號的 slug：%s", slug)
+	}
+
+	categoryID, err := s.findCategoryIDBySlug(ctx, strings.TrimSpace(fm.Category))
+	if err != nil {
+		return PostDto{}, err
+	}
+
+	blocks := utils.MarkdownToEditorJSBlocks(body)
+	if len(blocks) == 0 {
+		return PostDto{}, middleware.ErrContentEmpty
+	}
+	content, err := utils.MarshalEditorJS(blocks)
+	if err != nil {
+		return PostDto{}, middleware.Newf(middleware.ErrDataError.Code, "轉換 Editor.js 內容失敗：%v", err)
+	}
+
+	return s.CreatePost(CreatePostDto{
+		Title:         title,
+		CoverImageUrl: strings.TrimSpace(fm.Cover),
+		Content:       content,
+		CategoryID:    categoryID,
+		IsPublished:   fm.Published,
+		Slug:          slug,
+		Excerpt:       fm.Excerpt,
+		Tags:          fm.Tags,
+		SeoFieldsDto: SeoFieldsDto{
+			MetaDescription: fm.MetaDescription,
+			SocialTitle:     fm.SocialTitle,
+			OgImageUrl:      fm.OgImage,
+			CanonicalUrl:    fm.CanonicalUrl,
+			NoIndex:         fm.NoIndex,
+		},
+	})
+}
+
+func (s *postServiceImpl) findCategoryIDBySlug(ctx context.Context, slug string) (uint, error) {
+	if slug == "" {
+		return 0, middleware.Newf(middleware.ErrValidation.Code, "請在 front matter 填寫 category（分類 slug）")
+	}
+
+	var category entity.Category
+	err := s.db.NewSelect().
+		Model(&category).
+		Column("id").
+		Where("slug = ?", slug).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		// 分類改過 slug 時，舊的 slug 仍然可以使用
+		id, found, err := utils.FindSlugRedirect(ctx, s.db, utils.SlugEntityCategory, slug)
+		if err != nil {
+			return 0, middleware.ErrDB
+		}
+		if !found {
+			return 0, middleware.Newf(middleware.ErrNotFound.Code, "找不到分類：%s", slug)
+		}
+		return id, nil
+	} else if err != nil {
+		return 0, middleware.ErrDB
+	}
+	return category.ID, nil
+}
+
+// 取出內文開頭的一級標題，回傳標題與剩下的內文
+func takeMarkdownTitle(body string) (string, string) {
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		m := markdownTitleRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			return "", body
+		}
+		return m[1], strings.Join(lines[i+1:], "\n")
+	}
+	return "", body
+}
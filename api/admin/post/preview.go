@@ -0,0 +1,111 @@
+package post
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/middleware"
+	"blog-backend/common/preview"
+)
+
+// 預覽連結預設有效 72 小時
+const defaultPreviewHours = 72
+
+// 產生草稿預覽 token，前台以 /api/post/:slug?preview=<token> 讀取
+func (s *postServiceImpl) CreatePreviewToken(postID string, req CreatePreviewTokenDto) (PreviewTokenDto, error) {
+	ctx := context.Background()
+
+	var post entity.Post
+	err := s.db.NewSelect().
+		Model(&post).
+		Column("id").
+		Where("id = ?", postID).
+		Where("is_deleted = FALSE").
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return PreviewTokenDto{}, middleware.ErrNotFound
+	} else if err != nil {
+		return PreviewTokenDto{}, middleware.ErrDB
+	}
+
+	hours := req.ExpiresInHours
+	if hours <= 0 {
+		hours = defaultPreviewHours
+	}
+
+	token, record, err := preview.Issue(ctx, s.db, post.ID, time.Now().Add(time.Duration(hours)*time.Hour))
+	if errors.Is(err, preview.ErrSecretNotSet) {
+		return PreviewTokenDto{}, middleware.Newf(middleware.ErrInternal.Code, "尚未設定 PREVIEW_SIGNING_SECRET，無法產生預覽連結")
+	} else if err != nil {
+		return PreviewTokenDto{}, middleware.WrapDBErr("建立預覽連結失敗", err)
+	}
+
+	dto := toPreviewTokenDto(record, time.Now())
+	dto.Token = token
+	return dto, nil
+}
+
+func (s *postServiceImpl) GetPreviewTokens(postID string) ([]PreviewTokenDto, error) {
+	var records []entity.PreviewToken
+	err := s.db.NewSelect().
+		Model(&records).
+		Where("post_id = ?", postID).
+		Order("created_at DESC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, middleware.ErrDB
+	}
+
+	now := time.Now()
+	result := make([]PreviewTokenDto, 0, len(records))
+	for _, record := range records {
+		result = append(result, toPreviewTokenDto(record, now))
+	}
+	return result, nil
+}
+
+// 撤銷預覽連結，已撤銷的不會更新撤銷時間
+func (s *postServiceImpl) RevokePreviewToken(postID, tokenID string) error {
+	ctx := context.Background()
+
+	var record entity.PreviewToken
+	err := s.db.NewSelect().
+		Model(&record).
+		Where("id = ?", tokenID).
+		Where("post_id = ?", postID).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return middleware.ErrNotFound
+	} else if err != nil {
+		return middleware.ErrDB
+	}
+	if record.RevokedAt != nil {
+		return nil
+	}
+
+	_, err = s.db.NewUpdate().
+		Model((*entity.PreviewToken)(nil)).
+		Set("revoked_at = NOW()").
+		Where("id = ?", record.ID).
+		Exec(ctx)
+	if err != nil {
+		return middleware.WrapDBErr("撤銷預覽連結失敗", err)
+	}
+	return nil
+}
+
+func toPreviewTokenDto(record entity.PreviewToken, now time.Time) PreviewTokenDto {
+	return PreviewTokenDto{
+		ID:        record.ID,
+		PostID:    record.PostID,
+		ExpiresAt: record.ExpiresAt,
+		RevokedAt: record.RevokedAt,
+		Active:    record.RevokedAt == nil && now.Before(record.ExpiresAt),
+		CreatedAt: record.CreatedAt,
+	}
+}
@@ -0,0 +1,310 @@
+package post
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/middleware"
+	"blog-backend/common/utils"
+
+	"github.com/uptrace/bun"
+)
+
+// 版本來源
+const (
+	revisionSourceInitial = "initial" // 功能上線前的舊文章，第一次修改前補存的版本
+	revisionSourceCreate  = "create"
+	revisionSourceUpdate  = "update"
+	revisionSourceRestore = "restore"
+	revisionSourceBulk    = "bulk" // 批次發佈、下架或移動分類
+)
+
+func (s *postServiceImpl) GetPostRevisions(postID string) ([]PostRevisionDto, error) {
+	var revisions []entity.PostRevision
+	err := s.db.NewSelect().
+		Model(&revisions).
+		ExcludeColumn("content").
+		Where("post_id = ?", postID).
+		Order("id DESC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, middleware.ErrDB
+	}
+
+	result := make([]PostRevisionDto, 0, len(revisions))
+	for _, rev := range revisions {
+		result = append(result, toRevisionDto(rev))
+	}
+	return result, nil
+}
+
+func (s *postServiceImpl) GetPostRevision(postID, revisionID string) (PostRevisionDetailDto, error) {
+	rev, err := s.findRevision(context.Background(), postID, revisionID)
+	if err != nil {
+		return PostRevisionDetailDto{}, err
+	}
+	return PostRevisionDetailDto{
+		PostRevisionDto: toRevisionDto(rev),
+		Content:         rev.Content,
+	}, nil
+}
+
+func (s *postServiceImpl) DiffPostRevisions(postID, fromID, toID string) (RevisionDiffDto, error) {
+	ctx := context.Background()
+
+	from, err := s.findRevision(ctx, postID, fromID)
+	if err != nil {
+		return RevisionDiffDto{}, err
+	}
+	to, err := s.findRevision(ctx, postID, toID)
+	if err != nil {
+		return RevisionDiffDto{}, err
+	}
+
+	oldBlocks, err := utils.ParseEditorJsBlocks(from.Content)
+	if err != nil {
+		return RevisionDiffDto{}, middleware.Newf(middleware.ErrDataError.Code, "版本 %d 的內容無法解析：%v", from.ID, err)
+	}
+	newBlocks, err := utils.ParseEditorJsBlocks(to.Content)
+	if err != nil {
+		return RevisionDiffDto{}, middleware.Newf(middleware.ErrDataError.Code, "版本 %d 的內容無法解析：%v", to.ID, err)
+	}
+
+	return RevisionDiffDto{
+		From:   toRevisionDto(from),
+		To:     toRevisionDto(to),
+		Fields: diffRevisionFields(from, to),
+		Blocks: diffBlocks(oldBlocks, newBlocks),
+	}, nil
+}
+
+// 以舊版本內容再做一次更新，圖片會跟一般更新一樣重新整理狀態
+func (s *postServiceImpl) RestorePostRevision(postID, revisionID string, restoreReq RestorePostRevisionDto) (PostDto, error) {
+	ctx := context.Background()
+
+	// 還原會覆蓋整篇文章，和編輯一樣要帶版本號，避免蓋掉其他分頁較新的修改
+	if restoreReq.Version == nil {
+		return PostDto{}, middleware.ErrVersionRequired
+	}
+
+	rev, err := s.findRevision(ctx, postID, revisionID)
+	if err != nil {
+		return PostDto{}, err
+	}
+
+	// 排程時間與 SEO 設定不屬於版本內容，沿用文章目前的設定
+	var current entity.Post
+	err = s.db.NewSelect().
+		Model(&current).
+		Column("publish_at", "unpublish_at", "meta_description", "social_title", "og_image_url", "canonical_url", "no_index").
+		Where("id = ?", postID).
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return PostDto{}, middleware.ErrDB
+	}
+
+	req := UpdatePostDto{
+		Title:         rev.Title,
+		CoverImageUrl: rev.CoverImageUrl,
+		Content:       rev.Content,
+		Excerpt:       rev.Excerpt,
+		CategoryID:    rev.CategoryID,
+		IsPublished:   rev.IsPublished,
+		Slug:          rev.Slug,
+		Version:       restoreReq.Version,
+		PublishAt:     current.PublishAt,
+		UnpublishAt:   current.UnpublishAt,
+		SeoFieldsDto: SeoFieldsDto{
+			MetaDescription: current.MetaDescription,
+			SocialTitle:     current.SocialTitle,
+			OgImageUrl:      current.OgImageUrl,
+			CanonicalUrl:    current.CanonicalUrl,
+			NoIndex:         current.NoIndex,
+		},
+	}
+	return s.updatePost(postID, req, revisionSourceRestore, &rev.ID)
+}
+
+func (s *postServiceImpl) findRevision(ctx context.Context, postID, revisionID string) (entity.PostRevision, error) {
+	var rev entity.PostRevision
+	err := s.db.NewSelect().
+		Model(&rev).
+		Where("id = ?", revisionID).
+		Where("post_id = ?", postID).
+		Limit(1).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.PostRevision{}, middleware.ErrNotFound
+	} else if err != nil {
+		return entity.PostRevision{}, middleware.ErrDB
+	}
+	return rev, nil
+}
+
+// 在同一個交易中寫入文章的新版本
+func insertRevision(ctx context.Context, tx bun.Tx, post entity.Post, source string, restoredFrom *uint) error {
+	rev := entity.PostRevision{
+		PostID:        post.ID,
+		Title:         post.Title,
+		Slug:          post.Slug,
+		CategoryID:    post.CategoryID,
+		IsPublished:   post.IsPublished,
+		CoverImageUrl: post.CoverImageUrl,
+		Content:       post.Content,
+		Excerpt:       post.Excerpt,
+		Source:        source,
+		RestoredFrom:  restoredFrom,
+		CreatedAt:     post.UpdatedAt,
+	}
+	if _, err := tx.NewInsert().Model(&rev).Exec(ctx); err != nil {
+		return middleware.WrapDBErr("寫入文章版本失敗", err)
+	}
+	return nil
+}
+
+// 文章還沒有任何版本時，把目前的內容補存為初始版本，避免第一次修改就遺失舊內容
+func ensureInitialRevision(ctx context.Context, tx bun.Tx, post entity.Post) error {
+	count, err := tx.NewSelect().
+		Model((*entity.PostRevision)(nil)).
+		Where("post_id = ?", post.ID).
+		Count(ctx)
+	if err != nil {
+		return middleware.WrapDBErr("查詢文章版本失敗", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	return insertRevision(ctx, tx, post, revisionSourceInitial, nil)
+}
+
+func toRevisionDto(rev entity.PostRevision) PostRevisionDto {
+	return PostRevisionDto{
+		ID:            rev.ID,
+		PostID:        rev.PostID,
+		Title:         rev.Title,
+		Slug:          rev.Slug,
+		CategoryID:    rev.CategoryID,
+		IsPublished:   rev.IsPublished,
+		CoverImageUrl: rev.CoverImageUrl,
+		Source:        rev.Source,
+		RestoredFrom:  rev.RestoredFrom,
+		CreatedAt:     rev.CreatedAt,
+	}
+}
+
+// 比對文章欄位（內容以外）的差異
+func diffRevisionFields(from, to entity.PostRevision) []FieldDiffDto {
+	fields := []FieldDiffDto{}
+	add := func(name string, old, new interface{}) {
+		if old != new {
+			fields = append(fields, FieldDiffDto{Field: name, Old: old, New: new})
+		}
+	}
+	add("title", from.Title, to.Title)
+	add("slug", from.Slug, to.Slug)
+	add("categoryId", from.CategoryID, to.CategoryID)
+	add("isPublished", from.IsPublished, to.IsPublished)
+	add("coverImageUrl", from.CoverImageUrl, to.CoverImageUrl)
+	add("excerpt", from.Excerpt, to.Excerpt)
+	return fields
+}
+
+// 以 block 為單位做 LCS 比對
+// 有 Editor.js block id 時以 id 對齊（同一個 block 被修改會顯示為 modified），沒有 id 時以內容對齊
+func diffBlocks(oldBlocks, newBlocks []utils.EditorJsRawBlock) []BlockDiffDto {
+	oldKeys := make([]string, len(oldBlocks))
+	for i, b := range oldBlocks {
+		oldKeys[i] = blockKey(b)
+	}
+	newKeys := make([]string, len(newBlocks))
+	for i, b := range newBlocks {
+		newKeys[i] = blockKey(b)
+	}
+
+	// lcs[i][j] = oldKeys[i:] 與 newKeys[j:] 的最長共同子序列長度
+	lcs := make([][]int, len(oldKeys)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newKeys)+1)
+	}
+	for i := len(oldKeys) - 1; i >= 0; i-- {
+		for j := len(newKeys) - 1; j >= 0; j-- {
+			if oldKeys[i] == newKeys[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	result := []BlockDiffDto{}
+	i, j := 0, 0
+	for i < len(oldKeys) || j < len(newKeys) {
+		switch {
+		case i < len(oldKeys) && j < len(newKeys) && oldKeys[i] == newKeys[j]:
+			op := "unchanged"
+			if oldBlocks[i].Type != newBlocks[j].Type || !sameJSON(oldBlocks[i].Data, newBlocks[j].Data) {
+				op = "modified"
+			}
+			entry := BlockDiffDto{
+				Op:       op,
+				Type:     newBlocks[j].Type,
+				OldIndex: intPtr(i),
+				NewIndex: intPtr(j),
+			}
+			if op == "modified" {
+				entry.Old = oldBlocks[i].Data
+			}
+			entry.New = newBlocks[j].Data
+			result = append(result, entry)
+			i++
+			j++
+		case j < len(newKeys) && (i == len(oldKeys) || lcs[i][j+1] >= lcs[i+1][j]):
+			result = append(result, BlockDiffDto{
+				Op:       "added",
+				Type:     newBlocks[j].Type,
+				NewIndex: intPtr(j),
+				New:      newBlocks[j].Data,
+			})
+			j++
+		default:
+			result = append(result, BlockDiffDto{
+				Op:       "removed",
+				Type:     oldBlocks[i].Type,
+				OldIndex: intPtr(i),
+				Old:      oldBlocks[i].Data,
+			})
+			i++
+		}
+	}
+	return result
+}
+
+func blockKey(b utils.EditorJsRawBlock) string {
+	if b.ID != "" {
+		return "id:" + b.ID
+	}
+	return "content:" + b.Type + ":" + compactJSON(b.Data)
+}
+
+func sameJSON(a, b json.RawMessage) bool {
+	return compactJSON(a) == compactJSON(b)
+}
+
+func compactJSON(raw json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return strings.TrimSpace(string(raw))
+	}
+	return buf.String()
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -1,20 +1,27 @@
 package post
 
 import (
+	"blog-backend/common/mailer"
 	"blog-backend/common/middleware"
 	"blog-backend/common/model"
+	"blog-backend/common/newsletter"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"blog-backend/common/entity"
+	"blog-backend/common/search"
 	"blog-backend/common/utils"
+	"blog-backend/common/webhook"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -34,15 +41,43 @@ type PostService interface {
 	GeneratePresignedUploadURL(filename string) (UploadUrlDto, error)
 	GetAboutMe() (AboutMeDto, error)
 	UpdateAboutMe(req UpdateAboutMeDto) (AboutMeDto, error)
+	GetPostRevisions(postID string) ([]PostRevisionDto, error)
+	GetPostRevision(postID, revisionID string) (PostRevisionDetailDto, error)
+	DiffPostRevisions(postID, fromID, toID string) (RevisionDiffDto, error)
+	RestorePostRevision(postID, revisionID string, req RestorePostRevisionDto) (PostDto, error)
+	GetTags() ([]TagDto, error)
+	UpdateTag(id string, req UpdateTagDto) (TagDto, error)
+	MergeTags(sourceID string, req MergeTagDto) (TagDto, error)
+	GetTrashPosts(req GetPostListDto) (model.PaginatedResponse[TrashPostDto], error)
+	RestorePost(id string) (RestorePostResultDto, error)
+	PurgePost(id string) error
+	BulkPosts(req BulkPostDto) (BulkPostResultDto, error)
+	ImportMarkdown(req ImportMarkdownDto) (PostDto, error)
+	ExportArchive(w io.Writer) error
+	CreatePreviewToken(postID string, req CreatePreviewTokenDto) (PreviewTokenDto, error)
+	GetPreviewTokens(postID string) ([]PreviewTokenDto, error)
+	RevokePreviewToken(postID, tokenID string) error
+	GetComments(req GetCommentListDto) (model.PaginatedResponse[CommentDto], error)
+	ApproveComment(id string) (CommentDto, error)
+	RejectComment(id string) (CommentDto, error)
+	BulkComments(req BulkCommentDto) (BulkCommentResultDto, error)
+	GetWebhooks() ([]WebhookDto, error)
+	CreateWebhook(req CreateWebhookDto) (WebhookDto, error)
+	UpdateWebhook(id string, req UpdateWebhookDto) (WebhookDto, error)
+	DeleteWebhook(id string) error
+	GetWebhookDeliveries(req GetWebhookDeliveryListDto) (model.PaginatedResponse[WebhookDeliveryDto], error)
+	RedeliverWebhook(deliveryID string) (WebhookDeliveryDto, error)
 }
 
 type postServiceImpl struct {
-	db *bun.DB
+	db     *bun.DB
+	mailer mailer.Mailer
 }
 
 func NewPostService(db *bun.DB) PostService {
 	return &postServiceImpl{
-		db: db,
+		db:     db,
+		mailer: mailer.FromEnv(),
 	}
 }
 
@@ -62,7 +97,7 @@ func (s *postServiceImpl) GetPostList(req GetPostListDto) (model.PaginatedRespon
 
 	// 查詢分頁資料
 	var posts []entity.Post
-	query := s.db.NewSelect().Model(&posts).Where("is_deleted = false")
+	query := s.db.NewSelect().Model(&posts).ExcludeColumn("content").Where("is_deleted = false")
 
 	if req.Search != "" {
 		query = query.Where("title ILIKE ?", "%"+req.Search+"%")
@@ -85,7 +120,7 @@ func (s *postServiceImpl) GetPostList(req GetPostListDto) (model.PaginatedRespon
 			SortID:    (req.Page-1)*req.Limit + i + 1,
 			Id:        post.ID,
 			Title:     post.Title,
-			Summary:   utils.ExtractSummaryFromEditorJS(post.Content, 200),
+			Summary:   post.Summary,
 			CreatedAt: post.CreatedAt,
 			UpdatedAt: post.UpdatedAt,
 		})
@@ -101,30 +136,49 @@ func (s *postServiceImpl) GetPostList(req GetPostListDto) (model.PaginatedRespon
 }
 
 func (s *postServiceImpl) GetPostByID(id string) (PostDto, error) {
+	ctx := context.Background()
+
 	var post entity.Post
 	err := s.db.NewSelect().
 		Model(&post).
 		Where("post.id = ?", id).
 		Where("is_deleted = false").
 		Limit(1).
-		Scan(context.Background())
+		Scan(ctx)
 
 	if errors.Is(err, sql.ErrNoRows) {
 		return PostDto{}, middleware.ErrNotFound
 	} else if err != nil {
 		return PostDto{}, middleware.ErrDB
 	}
+
+	tags, err := loadPostTags(ctx, s.db, post.ID)
+	if err != nil {
+		return PostDto{}, err
+	}
 	dto := PostDto{
 		ID:            post.ID,
 		Title:         post.Title,
 		Content:       post.Content,
-		Summary:       utils.ExtractSummaryFromEditorJS(post.Content, 200),
+		Summary:       post.Summary,
+		Excerpt:       post.Excerpt,
 		CoverImageUrl: post.CoverImageUrl,
 		IsPublished:   post.IsPublished,
 		CategoryID:    post.CategoryID,
 		CreatedAt:     post.CreatedAt,
 		UpdatedAt:     post.UpdatedAt,
 		Slug:          post.Slug,
+		PublishAt:     post.PublishAt,
+		UnpublishAt:   post.UnpublishAt,
+		Tags:          tags,
+		Version:       post.Version,
+		SeoFieldsDto: SeoFieldsDto{
+			MetaDescription: post.MetaDescription,
+			SocialTitle:     post.SocialTitle,
+			OgImageUrl:      post.OgImageUrl,
+			CanonicalUrl:    post.CanonicalUrl,
+			NoIndex:         post.NoIndex,
+		},
 	}
 	return dto, nil
 }
@@ -142,6 +196,24 @@ func (s *postServiceImpl) CreatePost(req CreatePostDto) (PostDto, error) {
 	if strings.TrimSpace(req.Content) == "" || req.Content == "null" {
 		return PostDto{}, middleware.ErrContentEmpty
 	}
+	if err := validateEditorJSContent(req.Content); err != nil {
+		return PostDto{}, err
+	}
+	if req.Content, err = sanitizeEditorJSContent(req.Content); err != nil {
+		return PostDto{}, err
+	}
+
+	if err := normalizeSchedule(&req, now); err != nil {
+		return PostDto{}, err
+	}
+	if err := validateSeoFields(req); err != nil {
+		return PostDto{}, err
+	}
+
+	// slug 不能跟其他文章目前或曾經使用的 slug 重複
+	if err := checkPostSlug(ctx, tx, 0, req.Slug); err != nil {
+		return PostDto{}, err
+	}
 
 	// 建立 post
 	post := entity.Post{
@@ -150,11 +222,16 @@ func (s *postServiceImpl) CreatePost(req CreatePostDto) (PostDto, error) {
 		IsPublished:   req.IsPublished,
 		CoverImageUrl: req.CoverImageUrl,
 		Content:       req.Content,
+		Excerpt:       strings.TrimSpace(req.Excerpt),
 		CreatedAt:     now,
 		UpdatedAt:     now,
 		Slug:          req.Slug,
 		NeedsRefresh:  true,
+		PublishAt:     req.PublishAt,
+		UnpublishAt:   req.UnpublishAt,
 	}
+	applySeoFields(&post, req)
+	utils.ApplyPostStats(&post)
 	_, err = tx.NewInsert().Model(&post).Returning("id").Exec(ctx)
 	if err != nil {
 		return PostDto{}, middleware.ErrDB
@@ -192,40 +269,120 @@ func (s *postServiceImpl) CreatePost(req CreatePostDto) (PostDto, error) {
 		}
 	}
 
+	if err := syncPostTags(ctx, tx, post.ID, req.Tags); err != nil {
+		return PostDto{}, err
+	}
+
+	// 寫入第一個版本
+	if err := insertRevision(ctx, tx, post, revisionSourceCreate, nil); err != nil {
+		return PostDto{}, err
+	}
+
+	if err := search.IndexPost(ctx, tx, post.ID, post.Title, post.Content); err != nil {
+		return PostDto{}, middleware.WrapDBErr("更新搜尋索引失敗", err)
+	}
+
+	if err := search.RefreshRelatedPosts(ctx, tx, post.ID); err != nil {
+		return PostDto{}, middleware.WrapDBErr("更新相關文章失敗", err)
+	}
+
+	// 建立時就發佈：通知電子報訂閱者
+	notify := false
+	if post.IsPublished {
+		if notify, err = newsletter.ClaimPostNotification(ctx, tx, post.ID); err != nil {
+			return PostDto{}, middleware.WrapDBErr("建立電子報通知失敗", err)
+		}
+	}
+
+	events := []string{webhook.EventPostCreated}
+	if post.IsPublished {
+		events = append(events, webhook.EventPostPublished)
+	}
+	deliveryIDs, err := enqueuePostEvents(ctx, tx, post.ID, events...)
+	if err != nil {
+		return PostDto{}, err
+	}
+
+	if err := utils.BumpContentVersion(ctx, tx); err != nil {
+		return PostDto{}, middleware.WrapDBErr("更新內容版本失敗", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return PostDto{}, middleware.ErrTransaction
 	}
 
+	if notify {
+		s.notifySubscribers("CreatePost", post.ID)
+	}
+	dispatchWebhooks(s.db, "CreatePost", deliveryIDs)
+
 	// ✅ 清除快取 + 重新部署（不影響主流程）
 	if req.IsPublished {
-		go func() {
+		runInBackground(func() {
 			if err := utils.PurgeWorkerCacheAndDeployVercel(); err != nil {
 				fmt.Printf("⚠️ 部署失敗（CreatePost）：%v\n", err)
 			}
-		}()
+		})
 	}
 
 	return s.GetPostByID(fmt.Sprint(post.ID))
 }
 
 func (s *postServiceImpl) UpdatePost(id string, req UpdatePostDto) (PostDto, error) {
+	// 一定要帶版本號，避免兩個分頁同時編輯時後存的覆蓋先存的
+	if req.Version == nil {
+		return PostDto{}, middleware.ErrVersionRequired
+	}
+	// 空內容與 CreatePost 一樣回傳 ErrContentEmpty，不要被結構驗證搶先
+	if strings.TrimSpace(req.Content) == "" || req.Content == "null" {
+		return PostDto{}, middleware.ErrContentEmpty
+	}
+	// 還原舊版本、批次操作不經過這裡，舊資料不會因為規則變嚴格而無法還原
+	if err := validateEditorJSContent(req.Content); err != nil {
+		return PostDto{}, err
+	}
+	return s.updatePost(id, req, revisionSourceUpdate, nil)
+}
+
+// updatePost 更新文章並寫入新版本，restoredFrom 只有在還原舊版本時才會帶值
+// req.Version 有值時必須與目前的版本相同，否則回傳 ErrConflict
+func (s *postServiceImpl) updatePost(id string, req UpdatePostDto, source string, restoredFrom *uint) (PostDto, error) {
 	ctx := context.Background()
 
 	// 檢查空內容
 	if strings.TrimSpace(req.Content) == "" || req.Content == "null" {
 		return PostDto{}, middleware.ErrContentEmpty
 	}
+	// 還原舊版本時也會經過這裡，舊內容一樣要清理
+	content, err := sanitizeEditorJSContent(req.Content)
+	if err != nil {
+		return PostDto{}, err
+	}
+	req.Content = content
 
-	// 取得原本文章與 content block
+	if err := normalizeSchedule(&req, time.Now()); err != nil {
+		return PostDto{}, err
+	}
+	if err := validateSeoFields(req); err != nil {
+		return PostDto{}, err
+	}
+
+	// 取得原本文章與 content block；垃圾桶中的文章只能透過 RestorePost 還原，不能直接編輯
 	var post entity.Post
-	err := s.db.NewSelect().
+	err = s.db.NewSelect().
 		Model(&post).
 		Where("post.id = ?", id).
+		Where("is_deleted = false").
 		Limit(1).
 		Scan(ctx)
-	if err != nil {
+	if errors.Is(err, sql.ErrNoRows) {
+		return PostDto{}, middleware.ErrNotFound
+	} else if err != nil {
 		return PostDto{}, middleware.ErrDB
 	}
+	if req.Version != nil && *req.Version != post.Version {
+		return PostDto{}, versionConflict(post.Version)
+	}
 
 	// 比對新舊圖片 URL
 	oldUrls := extractImageUrls(post.Content)
@@ -233,7 +390,7 @@ func (s *postServiceImpl) UpdatePost(id string, req UpdatePostDto) (PostDto, err
 	added, removed := diffImageUrls(oldUrls, newUrls)
 	oldCover := post.CoverImageUrl
 	newCover := req.CoverImageUrl
-	coverChanged := oldCover != "" && oldCover != newCover
+	coverChanged := oldCover != newCover
 
 	// 開始 Transaction 更新文章與內容
 	tx, err := s.db.BeginTx(ctx, nil)
@@ -242,37 +399,56 @@ func (s *postServiceImpl) UpdatePost(id string, req UpdatePostDto) (PostDto, err
 	}
 	defer tx.Rollback()
 
+	// slug 有變更時，檢查是否可用並記錄舊 slug（讓舊連結可以轉址）
+	if req.Slug != post.Slug {
+		if err := checkPostSlug(ctx, tx, post.ID, req.Slug); err != nil {
+			return PostDto{}, err
+		}
+		if err := utils.RecordSlugChange(ctx, tx, utils.SlugEntityPost, post.ID, post.Slug, req.Slug); err != nil {
+			return PostDto{}, middleware.WrapDBErr("記錄舊 slug 失敗", err)
+		}
+	}
+
+	// 舊文章還沒有任何版本時，先把修改前的內容存成初始版本
+	if err := ensureInitialRevision(ctx, tx, post); err != nil {
+		return PostDto{}, err
+	}
+
 	// 更新主文章
-	_, err = tx.NewUpdate().
-		Model(&entity.Post{
-			ID:            post.ID,
-			Title:         req.Title,
-			CategoryID:    req.CategoryID,
-			IsPublished:   req.IsPublished,
-			CoverImageUrl: req.CoverImageUrl,
-			Content:       req.Content,
-			CreatedAt:     post.CreatedAt,
-			UpdatedAt:     time.Now(),
-			Slug:          req.Slug,
-			NeedsRefresh:  true,
-		}).
+	updated := entity.Post{
+		ID:            post.ID,
+		Title:         req.Title,
+		CategoryID:    req.CategoryID,
+		IsPublished:   req.IsPublished,
+		CoverImageUrl: req.CoverImageUrl,
+		Content:       req.Content,
+		Excerpt:       strings.TrimSpace(req.Excerpt),
+		CreatedAt:     post.CreatedAt,
+		UpdatedAt:     time.Now(),
+		Slug:          req.Slug,
+		NeedsRefresh:  true,
+		PublishAt:     req.PublishAt,
+		UnpublishAt:   req.UnpublishAt,
+		Version:       post.Version + 1,
+	}
+	applySeoFields(&updated, req)
+	utils.ApplyPostStats(&updated)
+	// 讀取之後到寫入之前被其他請求改過時，version 條件會讓這次更新不生效
+	res, err := tx.NewUpdate().
+		Model(&updated).
 		WherePK().
+		Where("version = ?", post.Version).
 		Exec(ctx)
 	if err != nil {
 		return PostDto{}, middleware.ErrDB
 	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return PostDto{}, s.currentPostConflict(ctx, post.ID)
+	}
 
-	// 新增新增的圖片
-	for _, url := range added {
-		_, _ = tx.NewInsert().
-			Model(&entity.Image{
-				URL:    url,
-				PostID: post.ID,
-				Type:   "inline",
-				Status: "active",
-			}).
-			Ignore().
-			Exec(ctx)
+	// 新增新增的圖片（曾被標記 pending_delete 的圖片會恢復為 active）
+	if err := activateImages(ctx, tx, post.ID, added, "inline"); err != nil {
+		return PostDto{}, err
 	}
 
 	// 標記被移除的圖片
@@ -286,40 +462,81 @@ func (s *postServiceImpl) UpdatePost(id string, req UpdatePostDto) (PostDto, err
 	}
 
 	if coverChanged {
-		_, _ = tx.NewUpdate().
-			Model((*entity.Image)(nil)).
-			Set("status = 'pending_delete', updated_at = NOW()").
-			Where("post_id = ?", post.ID).
-			Where("url = ? AND type = 'cover'", oldCover).
-			Exec(ctx)
+		if oldCover != "" {
+			_, _ = tx.NewUpdate().
+				Model((*entity.Image)(nil)).
+				Set("status = 'pending_delete', updated_at = NOW()").
+				Where("post_id = ?", post.ID).
+				Where("url = ? AND type = 'cover'", oldCover).
+				Exec(ctx)
+		}
 
 		if newCover != "" {
-			_, _ = tx.NewInsert().
-				Model(&entity.Image{
-					URL:       newCover,
-					PostID:    post.ID,
-					Type:      "cover",
-					Status:    "active",
-					CreatedAt: time.Now(),
-					UpdatedAt: time.Now(),
-				}).
-				Ignore().
-				Exec(ctx)
+			if err := activateImages(ctx, tx, post.ID, []string{newCover}, "cover"); err != nil {
+				return PostDto{}, err
+			}
 		}
 	}
 
+	// 有帶標籤才更新，沒帶（null）維持原本的標籤
+	if req.Tags != nil {
+		if err := syncPostTags(ctx, tx, post.ID, req.Tags); err != nil {
+			return PostDto{}, err
+		}
+	}
+
+	// 寫入新版本
+	if err := insertRevision(ctx, tx, updated, source, restoredFrom); err != nil {
+		return PostDto{}, err
+	}
+
+	if err := search.IndexPost(ctx, tx, updated.ID, updated.Title, updated.Content); err != nil {
+		return PostDto{}, middleware.WrapDBErr("更新搜尋索引失敗", err)
+	}
+
+	if err := search.RefreshRelatedPosts(ctx, tx, updated.ID); err != nil {
+		return PostDto{}, middleware.WrapDBErr("更新相關文章失敗", err)
+	}
+
+	// 從未發佈變成發佈：通知電子報訂閱者（每篇文章只會通知一次）
+	notify := false
+	if !post.IsPublished && updated.IsPublished {
+		if notify, err = newsletter.ClaimPostNotification(ctx, tx, updated.ID); err != nil {
+			return PostDto{}, middleware.WrapDBErr("建立電子報通知失敗", err)
+		}
+	}
+
+	events := []string{webhook.EventPostUpdated}
+	if !post.IsPublished && updated.IsPublished {
+		events = append(events, webhook.EventPostPublished)
+	}
+	deliveryIDs, err := enqueuePostEvents(ctx, tx, updated.ID, events...)
+	if err != nil {
+		return PostDto{}, err
+	}
+
+	if err := utils.BumpContentVersion(ctx, tx); err != nil {
+		return PostDto{}, middleware.WrapDBErr("更新內容版本失敗", err)
+	}
+
 	// 成功提交
 	if err := tx.Commit(); err != nil {
 		return PostDto{}, middleware.ErrTransaction
 	}
 
+	if notify {
+		s.notifySubscribers("UpdatePost", updated.ID)
+	}
+	dispatchWebhooks(s.db, "UpdatePost", deliveryIDs)
+
 	// ✅ 清除快取 + 重新部署（不影響主流程）
-	if req.IsPublished {
-		go func() {
+	// 原本已發佈的文章被改成未發佈（或排程下架）時，前台也需要更新
+	if req.IsPublished || post.IsPublished {
+		runInBackground(func() {
 			if err := utils.PurgeWorkerCacheAndDeployVercel(); err != nil {
-				fmt.Printf("⚠️ 部署失敗（CreatePost）：%v\n", err)
+				fmt.Printf("⚠️ 部署失敗（UpdatePost）：%v\n", err)
 			}
-		}()
+		})
 	}
 
 	return s.GetPostByID(fmt.Sprint(post.ID))
@@ -334,36 +551,31 @@ func (s *postServiceImpl) DeletePost(id string) error {
 	}
 	defer tx.Rollback()
 
-	// 將 images.status 改為 pending_delete
-	_, err = tx.NewUpdate().
-		Model((*entity.Image)(nil)).
-		Set("status = 'pending_delete', updated_at = NOW()").
-		Where("post_id = ?", id).
-		Exec(ctx)
-	if err != nil {
-		return middleware.ErrDB
+	if err := softDeletePostTx(ctx, tx, id); err != nil {
+		return err
 	}
 
-	// 軟刪除：將 is_deleted 設為 true
-	_, err = tx.NewUpdate().
-		Model((*entity.Post)(nil)).
-		Set("is_deleted = true, updated_at = NOW()").
-		Where("id = ?", id).
-		Exec(ctx)
+	deliveryIDs, err := enqueuePostEvents(ctx, tx, id, webhook.EventPostDeleted)
 	if err != nil {
-		return middleware.ErrDB
+		return err
+	}
+
+	if err := utils.BumpContentVersion(ctx, tx); err != nil {
+		return middleware.WrapDBErr("更新內容版本失敗", err)
 	}
 
 	if err := tx.Commit(); err != nil {
 		return err
 	}
 
+	dispatchWebhooks(s.db, "DeletePost", deliveryIDs)
+
 	// ✅ 清除快取 + 重新部署（不影響主流程）
-	go func() {
+	runInBackground(func() {
 		if err := utils.PurgeWorkerCacheAndDeployVercel(); err != nil {
 			fmt.Printf("⚠️ 部署失敗（DeletePost）：%v\n", err)
 		}
-	}()
+	})
 
 	return nil
 }
@@ -372,6 +584,7 @@ func (s *postServiceImpl) GetPostsByCategory(categoryID string) ([]PostListDto,
 	var posts []entity.Post
 	err := s.db.NewSelect().
 		Model(&posts).
+		ExcludeColumn("content").
 		Where("category_id = ?", categoryID).
 		Where("is_deleted = false").
 		Scan(context.Background())
@@ -383,7 +596,7 @@ func (s *postServiceImpl) GetPostsByCategory(categoryID string) ([]PostListDto,
 	for _, post := range posts {
 		dto := PostListDto{
 			Title:   post.Title,
-			Summary: utils.ExtractSummaryFromEditorJS(post.Content, 200),
+			Summary: post.Summary,
 		}
 		result = append(result, dto)
 	}
@@ -470,15 +683,31 @@ func (s *postServiceImpl) GetAboutMe() (AboutMeDto, error) {
 		ID:        about.ID,
 		Content:   about.HtmlContent,
 		UpdatedAt: about.UpdatedAt,
+		Version:   about.Version,
 	}, nil
 }
 
+// 關於我只有一筆資料，新增時固定使用這個 ID
+const aboutMeID = 1
+
 func (s *postServiceImpl) UpdateAboutMe(req UpdateAboutMeDto) (AboutMeDto, error) {
 	ctx := context.Background()
 	now := time.Now()
 
+	if req.Version == nil {
+		return AboutMeDto{}, middleware.ErrVersionRequired
+	}
+	if err := validateEditorJSContent(req.Content); err != nil {
+		return AboutMeDto{}, err
+	}
+	content, err := sanitizeEditorJSContent(req.Content)
+	if err != nil {
+		return AboutMeDto{}, err
+	}
+	req.Content = content
+
 	var existing entity.AboutMe
-	err := s.db.NewSelect().
+	err = s.db.NewSelect().
 		Model(&existing).
 		Limit(1).
 		Scan(ctx)
@@ -486,6 +715,10 @@ func (s *postServiceImpl) UpdateAboutMe(req UpdateAboutMeDto) (AboutMeDto, error
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return AboutMeDto{}, middleware.ErrDB
 	}
+	// 還沒有資料時版本號為 0
+	if *req.Version != existing.Version {
+		return AboutMeDto{}, versionConflict(existing.Version)
+	}
 
 	oldContent := existing.HtmlContent
 	oldUrls := extractImageUrls(oldContent)
@@ -500,28 +733,60 @@ func (s *postServiceImpl) UpdateAboutMe(req UpdateAboutMeDto) (AboutMeDto, error
 
 	// 有舊資料 → 更新
 	if existing.ID != 0 {
+		currentVersion := existing.Version
 		existing.HtmlContent = req.Content
 		existing.UpdatedAt = now
+		existing.Version = currentVersion + 1
 
-		_, err := tx.NewUpdate().
+		res, err := tx.NewUpdate().
 			Model(&existing).
 			WherePK().
+			Where("version = ?", currentVersion).
 			Exec(ctx)
 		if err != nil {
 			return AboutMeDto{}, middleware.ErrDB
 		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			// 讀取之後被其他請求改過
+			var latest entity.AboutMe
+			err := tx.NewSelect().
+				Model(&latest).
+				Column("version").
+				Where("id = ?", existing.ID).
+				Scan(ctx)
+			if err != nil {
+				return AboutMeDto{}, middleware.ErrDB
+			}
+			return AboutMeDto{}, versionConflict(latest.Version)
+		}
 	} else {
-		// 沒資料 → 新增
+		// 沒資料 → 新增；固定使用同一個 ID，兩個請求同時新增時只有一個會成功
 		existing = entity.AboutMe{
+			ID:          aboutMeID,
 			HtmlContent: req.Content,
 			UpdatedAt:   now,
+			Version:     1,
 		}
-		_, err := tx.NewInsert().
+		res, err := tx.NewInsert().
 			Model(&existing).
+			On("CONFLICT (id) DO NOTHING").
 			Exec(ctx)
 		if err != nil {
 			return AboutMeDto{}, middleware.ErrDB
 		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			// 讀取之後已經被其他請求新增
+			var latest entity.AboutMe
+			err := tx.NewSelect().
+				Model(&latest).
+				Column("version").
+				Where("id = ?", aboutMeID).
+				Scan(ctx)
+			if err != nil {
+				return AboutMeDto{}, middleware.ErrDB
+			}
+			return AboutMeDto{}, versionConflict(latest.Version)
+		}
 	}
 
 	// ✅ 移除的圖片 → 標記 pending_delete
@@ -548,24 +813,137 @@ func (s *postServiceImpl) UpdateAboutMe(req UpdateAboutMeDto) (AboutMeDto, error
 			Exec(ctx)
 	}
 
+	deliveryIDs, err := webhook.Enqueue(ctx, tx, webhook.EventAboutUpdated, webhook.AboutData{
+		ID:        existing.ID,
+		Version:   existing.Version,
+		UpdatedAt: existing.UpdatedAt,
+	})
+	if err != nil {
+		return AboutMeDto{}, middleware.WrapDBErr("建立 webhook 傳送紀錄失敗", err)
+	}
+
+	if err := utils.BumpContentVersion(ctx, tx); err != nil {
+		return AboutMeDto{}, middleware.WrapDBErr("更新內容版本失敗", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return AboutMeDto{}, middleware.Newf(middleware.ErrDB.Code, "提交交易失敗：%v", err)
 	}
 
+	dispatchWebhooks(s.db, "UpdateAboutMe", deliveryIDs)
+
 	// ✅ 清除快取 + 重新部署（不影響主流程）
-	go func() {
+	runInBackground(func() {
 		if err := utils.PurgeWorkerCacheAndDeployVercel(); err != nil {
 			fmt.Printf("⚠️ 部署失敗（CreatePost）：%v\n", err)
 		}
-	}()
+	})
 
 	return AboutMeDto{
 		ID:        existing.ID,
 		Content:   existing.HtmlContent,
 		UpdatedAt: existing.UpdatedAt,
+		Version:   existing.Version,
 	}, nil
 }
 
+// 版本號過期時回傳的錯誤，附上目前的版本號讓前端提示重新載入
+func versionConflict(currentVersion int) error {
+	return middleware.ErrConflict.WithData(model.ConflictData{CurrentVersion: currentVersion})
+}
+
+// 更新時 version 條件沒有命中：重新讀取目前的版本號組成 ErrConflict
+func (s *postServiceImpl) currentPostConflict(ctx context.Context, postID uint) error {
+	var post entity.Post
+	err := s.db.NewSelect().
+		Model(&post).
+		Column("version").
+		Where("id = ?", postID).
+		Scan(ctx)
+	if err != nil {
+		return middleware.ErrDB
+	}
+	return versionConflict(post.Version)
+}
+
+// ✅ 檢查文章 slug 是否已被其他文章使用（包含歷史 slug）
+func checkPostSlug(ctx context.Context, tx bun.Tx, postID uint, slug string) error {
+	taken, err := utils.IsSlugTaken(ctx, tx, utils.SlugEntityPost, postID, slug)
+	if err != nil {
+		return middleware.WrapDBErr("檢查 slug 失敗", err)
+	}
+	if taken {
+		return middleware.Newf(middleware.ErrValidation.Code, "slug 已被其他文章使用（或曾經使用）：%s", slug)
+	}
+	return nil
+}
+
+// ✅ 依 Editor.js 規則檢查內容（區塊類型、欄位、大小、圖片網址），Data 為每個問題的 JSON pointer 位置與原因
+func validateEditorJSContent(content string) error {
+	if issues := utils.ValidateEditorJS(content, utils.DefaultEditorJSRules()); len(issues) > 0 {
+		return middleware.ErrValidation.WithData(issues)
+	}
+	return nil
+}
+
+// ✅ 清理文字欄位中的 inline HTML（移除 script、事件屬性與 javascript: 等不安全的網址），前台可以直接插入
+func sanitizeEditorJSContent(content string) (string, error) {
+	clean, _, err := utils.SanitizeEditorJS(content)
+	if err != nil {
+		return "", middleware.Newf(middleware.ErrValidation.Code, "內容不是有效的 Editor.js JSON：%v", err)
+	}
+	return clean, nil
+}
+
+// ✅ SEO 網址欄位只接受 http(s) 的完整網址
+func validateSeoFields(req CreatePostDto) error {
+	fields := []struct{ name, value string }{
+		{"ogImageUrl", req.OgImageUrl},
+		{"canonicalUrl", req.CanonicalUrl},
+	}
+	for _, field := range fields {
+		value := strings.TrimSpace(field.value)
+		if value == "" {
+			continue
+		}
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return middleware.Newf(middleware.ErrValidation.Code, "%s 必須是 http 或 https 開頭的完整網址：%s", field.name, value)
+		}
+	}
+	return nil
+}
+
+func applySeoFields(post *entity.Post, req CreatePostDto) {
+	post.MetaDescription = strings.TrimSpace(req.MetaDescription)
+	post.SocialTitle = strings.TrimSpace(req.SocialTitle)
+	post.OgImageUrl = strings.TrimSpace(req.OgImageUrl)
+	post.CanonicalUrl = strings.TrimSpace(req.CanonicalUrl)
+	post.NoIndex = req.NoIndex
+}
+
+// ✅ 整理排程時間：已經過去的排程直接套用，未來的發佈排程在時間到之前維持未發佈
+func normalizeSchedule(req *CreatePostDto, now time.Time) error {
+	if req.PublishAt != nil && req.UnpublishAt != nil && !req.UnpublishAt.After(*req.PublishAt) {
+		return middleware.Newf(middleware.ErrValidation.Code, "下架時間必須晚於發佈時間")
+	}
+
+	if req.PublishAt != nil {
+		if req.PublishAt.After(now) {
+			req.IsPublished = false
+		} else {
+			req.IsPublished = true
+			req.PublishAt = nil
+		}
+	}
+
+	if req.UnpublishAt != nil && !req.UnpublishAt.After(now) {
+		req.IsPublished = false
+		req.UnpublishAt = nil
+	}
+	return nil
+}
+
 // ✅ 從文章內容中抓出所有圖片 URL（Editor.js JSON 解析）
 func extractImageUrls(content string) []string {
 	urls := []string{}
@@ -585,6 +963,43 @@ func extractImageUrls(content string) []string {
 	return urls
 }
 
+// ✅ 將圖片設為 active：已有紀錄（例如被標記 pending_delete）就恢復，沒有才新增
+func activateImages(ctx context.Context, tx bun.Tx, postID uint, urls []string, imageType string) error {
+	now := time.Now()
+	for _, url := range urls {
+		res, err := tx.NewUpdate().
+			Model((*entity.Image)(nil)).
+			Set("status = 'active', updated_at = NOW()").
+			Where("post_id = ?", postID).
+			Where("url = ?", url).
+			Where("type = ?", imageType).
+			Where("is_deleted = FALSE").
+			Exec(ctx)
+		if err != nil {
+			return middleware.ErrDB
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			continue
+		}
+
+		_, err = tx.NewInsert().
+			Model(&entity.Image{
+				URL:       url,
+				PostID:    postID,
+				Type:      imageType,
+				Status:    "active",
+				CreatedAt: now,
+				UpdatedAt: now,
+			}).
+			Ignore().
+			Exec(ctx)
+		if err != nil {
+			return middleware.ErrDB
+		}
+	}
+	return nil
+}
+
 // ✅ 新舊圖片 URL 差異比對：哪些是新增？哪些是被移除？
 func diffImageUrls(oldUrls, newUrls []string) (added, removed []string) {
 	oldSet := make(map[string]bool)
@@ -609,3 +1024,40 @@ func diffImageUrls(oldUrls, newUrls []string) (added, removed []string) {
 	}
 	return
 }
+
+// ✅ 寄新文章通知給電子報訂閱者（不影響主流程）
+func (s *postServiceImpl) notifySubscribers(action string, postIDs ...uint) {
+	runInBackground(func() {
+		ctx := context.Background()
+		for _, postID := range postIDs {
+			if _, err := newsletter.SendPostNotification(ctx, s.db, s.mailer, postID); err != nil {
+				fmt.Printf("⚠️ 新文章通知失敗（%s）：文章 ID=%d，錯誤：%v\n", action, postID, err)
+			}
+		}
+	})
+}
+
+// 背景工作（部署、電子報、webhook）不影響 API 回應；CLI 結束前要等這些工作完成，否則會被中斷
+var backgroundTasks sync.WaitGroup
+
+func runInBackground(task func()) {
+	backgroundTasks.Add(1)
+	go func() {
+		defer backgroundTasks.Done()
+		task()
+	}()
+}
+
+// WaitBackgroundTasks 等待背景工作（部署、電子報、webhook）完成，給 CLI 在結束前呼叫
+func WaitBackgroundTasks() {
+	backgroundTasks.Wait()
+}
+
+// ✅ 清除快取 + 重新部署（不影響主流程）
+func purgeCacheAndDeploy(action string) {
+	runInBackground(func() {
+		if err := utils.PurgeWorkerCacheAndDeployVercel(); err != nil {
+			fmt.Printf("⚠️ 部署失敗（%s）：%v\n", action, err)
+		}
+	})
+}
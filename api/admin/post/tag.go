@@ -0,0 +1,239 @@
+package post
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/middleware"
+	"blog-backend/common/utils"
+
+	"github.com/uptrace/bun"
+)
+
+func (s *postServiceImpl) GetTags() ([]TagDto, error) {
+	result := []TagDto{}
+	err := s.db.NewSelect().
+		Model((*entity.Tag)(nil)).
+		ColumnExpr("tag.id, tag.name, tag.slug").
+		ColumnExpr("COUNT(pt.post_id) AS post_count").
+		Join("LEFT JOIN post_tags AS pt ON pt.tag_id = tag.id").
+		Group("tag.id").
+		Order("tag.name ASC").
+		Scan(context.Background(), &result)
+	if err != nil {
+		return nil, middleware.ErrDB
+	}
+	return result, nil
+}
+
+func (s *postServiceImpl) UpdateTag(id string, req UpdateTagDto) (TagDto, error) {
+	ctx := context.Background()
+
+	tag, err := findTag(ctx, s.db, id)
+	if err != nil {
+		return TagDto{}, err
+	}
+
+	if req.Slug != "" && req.Slug != tag.Slug {
+		if !utils.IsValidSlug(req.Slug) {
+			return TagDto{}, middleware.Newf(middleware.ErrValidation.Code, "slug 格式錯誤，只能包含小寫英數字與連字號：%s", req.Slug)
+		}
+		count, err := s.db.NewSelect().
+			Model((*entity.Tag)(nil)).
+			Where("slug = ?", req.Slug).
+			Count(ctx)
+		if err != nil {
+			return TagDto{}, middleware.ErrDB
+		}
+		if count > 0 {
+			return TagDto{}, middleware.Newf(middleware.ErrValidation.Code, "slug 已被使用：%s，如果要合併請使用合併功能", req.Slug)
+		}
+		tag.Slug = req.Slug
+	}
+
+	tag.Name = req.Name
+	tag.UpdatedAt = time.Now()
+	_, err = s.db.NewUpdate().
+		Model(&tag).
+		Column("name", "slug", "updated_at").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return TagDto{}, middleware.ErrDB
+	}
+
+	purgeCacheAndDeploy("UpdateTag")
+
+	return s.getTagDto(ctx, tag.ID)
+}
+
+// 把 source 標籤的文章全部移到 target，然後刪除 source
+func (s *postServiceImpl) MergeTags(sourceID string, req MergeTagDto) (TagDto, error) {
+	ctx := context.Background()
+
+	source, err := findTag(ctx, s.db, sourceID)
+	if err != nil {
+		return TagDto{}, err
+	}
+	target, err := findTag(ctx, s.db, req.TargetID)
+	if err != nil {
+		return TagDto{}, err
+	}
+	if source.ID == target.ID {
+		return TagDto{}, middleware.Newf(middleware.ErrValidation.Code, "不能把標籤合併到自己")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return TagDto{}, middleware.ErrTransaction
+	}
+	defer tx.Rollback()
+
+	// 兩個標籤都有的文章只保留一筆關聯
+	_, err = tx.NewRaw(
+		"INSERT INTO post_tags (post_id, tag_id) SELECT post_id, ? FROM post_tags WHERE tag_id = ? ON CONFLICT DO NOTHING",
+		target.ID, source.ID,
+	).Exec(ctx)
+	if err != nil {
+		return TagDto{}, middleware.WrapDBErr("合併標籤失敗", err)
+	}
+
+	_, err = tx.NewDelete().
+		Model((*entity.PostTag)(nil)).
+		Where("tag_id = ?", source.ID).
+		Exec(ctx)
+	if err != nil {
+		return TagDto{}, middleware.WrapDBErr("合併標籤失敗", err)
+	}
+
+	_, err = tx.NewDelete().
+		Model(&source).
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return TagDto{}, middleware.WrapDBErr("刪除標籤失敗", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return TagDto{}, middleware.ErrTransaction
+	}
+
+	purgeCacheAndDeploy("MergeTags")
+
+	return s.getTagDto(ctx, target.ID)
+}
+
+func (s *postServiceImpl) getTagDto(ctx context.Context, id uint) (TagDto, error) {
+	var dto TagDto
+	err := s.db.NewSelect().
+		Model((*entity.Tag)(nil)).
+		ColumnExpr("tag.id, tag.name, tag.slug").
+		ColumnExpr("COUNT(pt.post_id) AS post_count").
+		Join("LEFT JOIN post_tags AS pt ON pt.tag_id = tag.id").
+		Where("tag.id = ?", id).
+		Group("tag.id").
+		Scan(ctx, &dto)
+	if err != nil {
+		return TagDto{}, middleware.ErrDB
+	}
+	return dto, nil
+}
+
+func findTag(ctx context.Context, db bun.IDB, id interface{}) (entity.Tag, error) {
+	var tag entity.Tag
+	err := db.NewSelect().
+		Model(&tag).
+		Where("id = ?", id).
+		Limit(1).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Tag{}, middleware.Newf(middleware.ErrNotFound.Code, "找不到標籤：%v", id)
+	} else if err != nil {
+		return entity.Tag{}, middleware.ErrDB
+	}
+	return tag, nil
+}
+
+// 在交易中把文章的標籤設定成 slugs，不存在的標籤會以 slug 當名稱自動建立
+func syncPostTags(ctx context.Context, tx bun.Tx, postID uint, slugs []string) error {
+	tagIDs := []uint{}
+	seen := make(map[string]bool)
+
+	for _, raw := range slugs {
+		slug := strings.ToLower(strings.TrimSpace(raw))
+		if slug == "" || seen[slug] {
+			continue
+		}
+		if !utils.IsValidSlug(slug) {
+			return middleware.Newf(middleware.ErrValidation.Code, "標籤 slug 格式錯誤，只能包含小寫英數字與連字號：%s", raw)
+		}
+		seen[slug] = true
+
+		now := time.Now()
+		tag := entity.Tag{Name: slug, Slug: slug, CreatedAt: now, UpdatedAt: now}
+		_, err := tx.NewInsert().
+			Model(&tag).
+			On("CONFLICT (slug) DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return middleware.WrapDBErr("建立標籤失敗", err)
+		}
+
+		var id uint
+		err = tx.NewSelect().
+			Model((*entity.Tag)(nil)).
+			Column("id").
+			Where("slug = ?", slug).
+			Scan(ctx, &id)
+		if err != nil {
+			return middleware.WrapDBErr("查詢標籤失敗", err)
+		}
+		tagIDs = append(tagIDs, id)
+	}
+
+	_, err := tx.NewDelete().
+		Model((*entity.PostTag)(nil)).
+		Where("post_id = ?", postID).
+		Exec(ctx)
+	if err != nil {
+		return middleware.WrapDBErr("更新文章標籤失敗", err)
+	}
+
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	postTags := make([]entity.PostTag, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		postTags = append(postTags, entity.PostTag{PostID: postID, TagID: id})
+	}
+	if _, err := tx.NewInsert().Model(&postTags).Exec(ctx); err != nil {
+		return middleware.WrapDBErr("更新文章標籤失敗", err)
+	}
+	return nil
+}
+
+// 取得文章的標籤
+func loadPostTags(ctx context.Context, db bun.IDB, postID uint) ([]TagDto, error) {
+	var tags []entity.Tag
+	err := db.NewSelect().
+		Model(&tags).
+		Join("JOIN post_tags AS pt ON pt.tag_id = tag.id").
+		Where("pt.post_id = ?", postID).
+		Order("tag.name ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, middleware.ErrDB
+	}
+
+	result := make([]TagDto, 0, len(tags))
+	for _, tag := range tags {
+		result = append(result, TagDto{ID: tag.ID, Name: tag.Name, Slug: tag.Slug})
+	}
+	return result, nil
+}
@@ -0,0 +1,221 @@
+package post
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/middleware"
+	"blog-backend/common/model"
+	"blog-backend/common/search"
+	"blog-backend/common/utils"
+	"blog-backend/common/webhook"
+
+	"github.com/uptrace/bun"
+)
+
+func (s *postServiceImpl) GetTrashPosts(req GetPostListDto) (model.PaginatedResponse[TrashPostDto], error) {
+	ctx := context.Background()
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 15
+	}
+
+	total, err := s.db.NewSelect().
+		Model((*entity.Post)(nil)).
+		Where("is_deleted = TRUE").
+		Count(ctx)
+	if err != nil {
+		return model.PaginatedResponse[TrashPostDto]{}, middleware.ErrDB
+	}
+
+	var posts []entity.Post
+	err = s.db.NewSelect().
+		Model(&posts).
+		ExcludeColumn("content").
+		Where("is_deleted = TRUE").
+		OrderExpr("COALESCE(deleted_at, updated_at) DESC").
+		Limit(req.Limit).
+		Offset((req.Page - 1) * req.Limit).
+		Scan(ctx)
+	if err != nil {
+		return model.PaginatedResponse[TrashPostDto]{}, middleware.ErrDB
+	}
+
+	retention := utils.TrashRetention()
+	var result []TrashPostDto
+	for _, post := range posts {
+		// 功能上線前刪除的文章沒有 deleted_at，以最後更新時間代替
+		deletedAt := post.DeletedAt
+		if deletedAt == nil {
+			deletedAt = &post.UpdatedAt
+		}
+		purgeAt := deletedAt.Add(retention)
+
+		result = append(result, TrashPostDto{
+			ID:            post.ID,
+			Title:         post.Title,
+			Slug:          post.Slug,
+			CategoryID:    post.CategoryID,
+			IsPublished:   post.IsPublished,
+			CoverImageUrl: post.CoverImageUrl,
+			DeletedAt:     deletedAt,
+			PurgeAt:       &purgeAt,
+		})
+	}
+
+	return model.PaginatedResponse[TrashPostDto]{
+		Page:       req.Page,
+		Limit:      req.Limit,
+		TotalCount: total,
+		Data:       result,
+	}, nil
+}
+
+func (s *postServiceImpl) RestorePost(id string) (RestorePostResultDto, error) {
+	ctx := context.Background()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return RestorePostResultDto{}, middleware.ErrTransaction
+	}
+	defer tx.Rollback()
+
+	post, missing, err := restorePostTx(ctx, tx, id)
+	if err != nil {
+		return RestorePostResultDto{}, err
+	}
+
+	deliveryIDs, err := enqueuePostEvents(ctx, tx, post.ID, webhook.EventPostUpdated)
+	if err != nil {
+		return RestorePostResultDto{}, err
+	}
+
+	if err := utils.BumpContentVersion(ctx, tx); err != nil {
+		return RestorePostResultDto{}, middleware.WrapDBErr("更新內容版本失敗", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return RestorePostResultDto{}, middleware.ErrTransaction
+	}
+
+	dispatchWebhooks(s.db, "RestorePost", deliveryIDs)
+
+	if post.IsPublished {
+		purgeCacheAndDeploy("RestorePost")
+	}
+
+	dto, err := s.GetPostByID(fmt.Sprint(post.ID))
+	if err != nil {
+		return RestorePostResultDto{}, err
+	}
+	return RestorePostResultDto{Post: dto, MissingImages: missing}, nil
+}
+
+// 永久刪除垃圾桶內的文章
+func (s *postServiceImpl) PurgePost(id string) error {
+	ctx := context.Background()
+
+	var post entity.Post
+	err := s.db.NewSelect().
+		Model(&post).
+		Column("id").
+		Where("id = ?", id).
+		Where("is_deleted = TRUE").
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return middleware.ErrNotFound
+	} else if err != nil {
+		return middleware.ErrDB
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return middleware.ErrTransaction
+	}
+	defer tx.Rollback()
+
+	if err := utils.HardDeletePost(ctx, tx, post.ID); err != nil {
+		return middleware.WrapDBErr("永久刪除文章失敗", err)
+	}
+
+	if err := utils.BumpContentVersion(ctx, tx); err != nil {
+		return middleware.WrapDBErr("更新內容版本失敗", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return middleware.ErrTransaction
+	}
+	return nil
+}
+
+// 在交易中把文章移出垃圾桶，並把內容仍在使用的圖片恢復為 active
+// 已被 CleanPendingImages 從 R2 刪除的圖片無法恢復，會以 missing 回傳
+func restorePostTx(ctx context.Context, tx bun.Tx, id interface{}) (entity.Post, []string, error) {
+	var post entity.Post
+	err := tx.NewSelect().
+		Model(&post).
+		Where("id = ?", id).
+		Where("is_deleted = TRUE").
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Post{}, nil, middleware.ErrNotFound
+	} else if err != nil {
+		return entity.Post{}, nil, middleware.ErrDB
+	}
+
+	_, err = tx.NewUpdate().
+		Model((*entity.Post)(nil)).
+		Set("is_deleted = FALSE, deleted_at = NULL, needs_refresh = TRUE, updated_at = NOW(), version = version + 1").
+		Where("id = ?", post.ID).
+		Exec(ctx)
+	if err != nil {
+		return entity.Post{}, nil, middleware.ErrDB
+	}
+
+	type usedImage struct {
+		url       string
+		imageType string
+	}
+	var used []usedImage
+	for _, url := range extractImageUrls(post.Content) {
+		used = append(used, usedImage{url: url, imageType: "inline"})
+	}
+	if post.CoverImageUrl != "" {
+		used = append(used, usedImage{url: post.CoverImageUrl, imageType: "cover"})
+	}
+
+	missing := []string{}
+	for _, img := range used {
+		res, err := tx.NewUpdate().
+			Model((*entity.Image)(nil)).
+			Set("status = 'active', updated_at = NOW()").
+			Where("post_id = ?", post.ID).
+			Where("url = ?", img.url).
+			Where("type = ?", img.imageType).
+			Where("is_deleted = FALSE").
+			Exec(ctx)
+		if err != nil {
+			return entity.Post{}, nil, middleware.ErrDB
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			missing = append(missing, img.url)
+		}
+	}
+
+	if err := search.IndexPost(ctx, tx, post.ID, post.Title, post.Content); err != nil {
+		return entity.Post{}, nil, middleware.WrapDBErr("更新搜尋索引失敗", err)
+	}
+	if err := search.RefreshRelatedPosts(ctx, tx, post.ID); err != nil {
+		return entity.Post{}, nil, middleware.WrapDBErr("更新相關文章失敗", err)
+	}
+
+	return post, missing, nil
+}
@@ -0,0 +1,333 @@
+package post
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/middleware"
+	"blog-backend/common/model"
+	"blog-backend/common/webhook"
+
+	"github.com/uptrace/bun"
+)
+
+// webhook 列表
+func (s *postServiceImpl) GetWebhooks() ([]WebhookDto, error) {
+	var subscriptions []entity.WebhookSubscription
+	err := s.db.NewSelect().
+		Model(&subscriptions).
+		OrderExpr("id ASC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, middleware.ErrDB
+	}
+
+	result := []WebhookDto{}
+	for _, subscription := range subscriptions {
+		result = append(result, toWebhookDto(subscription))
+	}
+	return result, nil
+}
+
+// 新增 webhook，簽章金鑰由伺服器產生
+func (s *postServiceImpl) CreateWebhook(req CreateWebhookDto) (WebhookDto, error) {
+	ctx := context.Background()
+
+	events, err := normalizeWebhookEvents(req.Events)
+	if err != nil {
+		return WebhookDto{}, err
+	}
+	if err := validateWebhookURL(req.URL); err != nil {
+		return WebhookDto{}, err
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return WebhookDto{}, middleware.Newf(middleware.ErrDataError.Code, "產生簽章金鑰失敗：%v", err)
+	}
+
+	now := time.Now()
+	subscription := entity.WebhookSubscription{
+		Name:      strings.TrimSpace(req.Name),
+		URL:       strings.TrimSpace(req.URL),
+		Secret:    secret,
+		Events:    events,
+		IsActive:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if _, err := s.db.NewInsert().Model(&subscription).Returning("id").Exec(ctx); err != nil {
+		return WebhookDto{}, middleware.WrapDBErr("新增 webhook 失敗", err)
+	}
+	return toWebhookDto(subscription), nil
+}
+
+// 修改 webhook；停用或修改事件只影響之後的事件，已建立的傳送紀錄照常重試
+func (s *postServiceImpl) UpdateWebhook(id string, req UpdateWebhookDto) (WebhookDto, error) {
+	ctx := context.Background()
+
+	subscription, err := findWebhook(ctx, s.db, id)
+	if err != nil {
+		return WebhookDto{}, err
+	}
+
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return WebhookDto{}, middleware.Newf(middleware.ErrValidation.Code, "webhook 名稱不能為空")
+		}
+		subscription.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return WebhookDto{}, err
+		}
+		subscription.URL = strings.TrimSpace(*req.URL)
+	}
+	if req.Events != nil {
+		if subscription.Events, err = normalizeWebhookEvents(req.Events); err != nil {
+			return WebhookDto{}, err
+		}
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+	if req.RotateSecret {
+		if subscription.Secret, err = webhook.NewSecret(); err != nil {
+			return WebhookDto{}, middleware.Newf(middleware.ErrDataError.Code, "產生簽章金鑰失敗：%v", err)
+		}
+	}
+
+	subscription.UpdatedAt = time.Now()
+	_, err = s.db.NewUpdate().
+		Model(&subscription).
+		Column("name", "url", "secret", "events", "is_active", "updated_at").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return WebhookDto{}, middleware.WrapDBErr("更新 webhook 失敗", err)
+	}
+	return toWebhookDto(subscription), nil
+}
+
+// 刪除 webhook 與它的傳送紀錄
+func (s *postServiceImpl) DeleteWebhook(id string) error {
+	ctx := context.Background()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return middleware.ErrTransaction
+	}
+	defer tx.Rollback()
+
+	subscription, err := findWebhook(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NewDelete().
+		Model((*entity.WebhookDelivery)(nil)).
+		Where("subscription_id = ?", subscription.ID).
+		Exec(ctx)
+	if err != nil {
+		return middleware.WrapDBErr("刪除 webhook 傳送紀錄失敗", err)
+	}
+	if _, err := tx.NewDelete().Model(&subscription).WherePK().Exec(ctx); err != nil {
+		return middleware.WrapDBErr("刪除 webhook 失敗", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return middleware.ErrTransaction
+	}
+	return nil
+}
+
+// webhook 傳送紀錄，最新的在前
+func (s *postServiceImpl) GetWebhookDeliveries(req GetWebhookDeliveryListDto) (model.PaginatedResponse[WebhookDeliveryDto], error) {
+	ctx := context.Background()
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+
+	filter := func(q *bun.SelectQuery) *bun.SelectQuery {
+		if req.WebhookID != 0 {
+			q = q.Where("subscription_id = ?", req.WebhookID)
+		}
+		if req.Status != "" {
+			q = q.Where("status = ?", req.Status)
+		}
+		if req.Event != "" {
+			q = q.Where("event = ?", req.Event)
+		}
+		return q
+	}
+
+	total, err := s.db.NewSelect().
+		Model((*entity.WebhookDelivery)(nil)).
+		Apply(filter).
+		Count(ctx)
+	if err != nil {
+		return model.PaginatedResponse[WebhookDeliveryDto]{}, middleware.ErrDB
+	}
+
+	var deliveries []entity.WebhookDelivery
+	err = s.db.NewSelect().
+		Model(&deliveries).
+		Apply(filter).
+		OrderExpr("id DESC").
+		Limit(req.Limit).
+		Offset((req.Page - 1) * req.Limit).
+		Scan(ctx)
+	if err != nil {
+		return model.PaginatedResponse[WebhookDeliveryDto]{}, middleware.ErrDB
+	}
+
+	result := []WebhookDeliveryDto{}
+	for _, delivery := range deliveries {
+		result = append(result, toWebhookDeliveryDto(delivery))
+	}
+
+	return model.PaginatedResponse[WebhookDeliveryDto]{
+		Page:       req.Page,
+		Limit:      req.Limit,
+		TotalCount: total,
+		Data:       result,
+	}, nil
+}
+
+// 手動重送一筆傳送紀錄（等待回應後回傳結果）
+func (s *postServiceImpl) RedeliverWebhook(deliveryID string) (WebhookDeliveryDto, error) {
+	id, err := strconv.ParseInt(deliveryID, 10, 64)
+	if err != nil {
+		return WebhookDeliveryDto{}, middleware.ErrBadRequest
+	}
+
+	delivery, err := webhook.Redeliver(context.Background(), s.db, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return WebhookDeliveryDto{}, middleware.Newf(middleware.ErrNotFound.Code, "找不到傳送紀錄：%d", id)
+	} else if err != nil {
+		return WebhookDeliveryDto{}, middleware.WrapDBErr("重送 webhook 失敗", err)
+	}
+	return toWebhookDeliveryDto(delivery), nil
+}
+
+// 建立文章事件的傳送紀錄（在交易中讀取異動後的文章）
+func enqueuePostEvents(ctx context.Context, tx bun.Tx, postID interface{}, events ...string) ([]int64, error) {
+	var post entity.Post
+	err := tx.NewSelect().
+		Model(&post).
+		Column("id", "title", "slug", "category_id", "is_published", "is_deleted", "summary", "updated_at").
+		Where("id = ?", postID).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, middleware.ErrDB
+	}
+
+	ids := []int64{}
+	for _, event := range events {
+		created, err := webhook.Enqueue(ctx, tx, event, webhook.NewPostData(post))
+		if err != nil {
+			return nil, middleware.WrapDBErr("建立 webhook 傳送紀錄失敗", err)
+		}
+		ids = append(ids, created...)
+	}
+	return ids, nil
+}
+
+// ✅ 送出 webhook（不影響主流程，失敗的由 batch 重試）
+func dispatchWebhooks(db bun.IDB, action string, ids []int64) {
+	if len(ids) == 0 {
+		return
+	}
+	runInBackground(func() {
+		if _, err := webhook.Deliver(context.Background(), db, ids); err != nil {
+			fmt.Printf("⚠️ webhook 傳送失敗（%s）：%v\n", action, err)
+		}
+	})
+}
+
+func findWebhook(ctx context.Context, db bun.IDB, id string) (entity.WebhookSubscription, error) {
+	var subscription entity.WebhookSubscription
+	err := db.NewSelect().
+		Model(&subscription).
+		Where("id = ?", id).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.WebhookSubscription{}, middleware.Newf(middleware.ErrNotFound.Code, "找不到 webhook：%s", id)
+	} else if err != nil {
+		return entity.WebhookSubscription{}, middleware.ErrDB
+	}
+	return subscription, nil
+}
+
+// 去除重複與空白，並確認每個事件都存在
+func normalizeWebhookEvents(events []string) ([]string, error) {
+	result := []string{}
+	seen := make(map[string]bool)
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if event == "" || seen[event] {
+			continue
+		}
+		if !webhook.ValidFilter(event) {
+			return nil, middleware.Newf(middleware.ErrValidation.Code, "不支援的事件：%s（可用：%s，或以 類別.*、* 訂閱多個事件）", event, strings.Join(webhook.Events, "、"))
+		}
+		seen[event] = true
+		result = append(result, event)
+	}
+	if len(result) == 0 {
+		return nil, middleware.Newf(middleware.ErrValidation.Code, "請至少訂閱一個事件")
+	}
+	return result, nil
+}
+
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return middleware.Newf(middleware.ErrValidation.Code, "webhook 網址必須是 http 或 https：%s", raw)
+	}
+	return nil
+}
+
+func toWebhookDto(subscription entity.WebhookSubscription) WebhookDto {
+	return WebhookDto{
+		ID:        subscription.ID,
+		Name:      subscription.Name,
+		URL:       subscription.URL,
+		Secret:    subscription.Secret,
+		Events:    subscription.Events,
+		IsActive:  subscription.IsActive,
+		CreatedAt: subscription.CreatedAt,
+		UpdatedAt: subscription.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryDto(delivery entity.WebhookDelivery) WebhookDeliveryDto {
+	return WebhookDeliveryDto{
+		ID:             delivery.ID,
+		WebhookID:      delivery.SubscriptionID,
+		Event:          delivery.Event,
+		Payload:        json.RawMessage(delivery.Payload),
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+}
@@ -23,6 +23,15 @@ func (api *BatchAPI) RegisterRoutes(r *gin.Engine) {
 	apiGroup := r.Group("/api/batch")
 	{
 		apiGroup.POST("/clean-images", api.CleanPendingImages)
+		apiGroup.POST("/publish-scheduled", api.PublishScheduledPosts)
+		apiGroup.POST("/rebuild-search-index", api.RebuildSearchIndex)
+		apiGroup.POST("/purge-trash", api.PurgeExpiredTrash)
+		apiGroup.POST("/backfill-post-metadata", api.BackfillPostMetadata)
+		apiGroup.POST("/rebuild-related-posts", api.RebuildRelatedPosts)
+		apiGroup.POST("/sanitize-content", api.SanitizeStoredContent)
+		apiGroup.POST("/rollup-post-views", api.RollupPostViews)
+		apiGroup.POST("/send-post-notifications", api.SendPostNotifications)
+		apiGroup.POST("/retry-webhooks", api.RetryWebhookDeliveries)
 	}
 }
 
@@ -36,3 +45,102 @@ func (api *BatchAPI) CleanPendingImages(c *gin.Context) {
 
 	c.Set("data", count)
 }
+
+// PublishScheduledPosts 套用已到期的排程發佈／下架，並觸發一次清快取與部署
+func (api *BatchAPI) PublishScheduledPosts(c *gin.Context) {
+	result, err := api.service.PublishScheduledPosts()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", result)
+}
+
+// RebuildSearchIndex 重新建立所有文章的搜尋索引
+func (api *BatchAPI) RebuildSearchIndex(c *gin.Context) {
+	count, err := api.service.RebuildSearchIndex()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", count)
+}
+
+// PurgeExpiredTrash 永久刪除在垃圾桶超過保留期限（TRASH_RETENTION_DAYS）的文章
+func (api *BatchAPI) PurgeExpiredTrash(c *gin.Context) {
+	count, err := api.service.PurgeExpiredTrash()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", count)
+}
+
+// BackfillPostMetadata 重新計算所有文章的目錄、字數、閱讀時間與摘要
+func (api *BatchAPI) BackfillPostMetadata(c *gin.Context) {
+	count, err := api.service.BackfillPostMetadata()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", count)
+}
+
+// RebuildRelatedPosts 重新計算所有文章的相關文章（需先建立搜尋索引）
+func (api *BatchAPI) RebuildRelatedPosts(c *gin.Context) {
+	count, err := api.service.RebuildRelatedPosts()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", count)
+}
+
+// SanitizeStoredContent 用目前的 HTML 白名單重新清理所有文章與關於我的內容
+func (api *BatchAPI) SanitizeStoredContent(c *gin.Context) {
+	result, err := api.service.SanitizeStoredContent()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", result)
+}
+
+// RollupPostViews 把文章瀏覽紀錄彙總成每日瀏覽數（熱門文章、熱門趨勢使用）
+func (api *BatchAPI) RollupPostViews(c *gin.Context) {
+	count, err := api.service.RollupPostViews()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", count)
+}
+
+// SendPostNotifications 重新寄送還沒寄出的新文章電子報通知
+func (api *BatchAPI) SendPostNotifications(c *gin.Context) {
+	count, err := api.service.SendPostNotifications()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", count)
+}
+
+// RetryWebhookDeliveries 重送失敗且到了重試時間的 webhook（指數退避）
+func (api *BatchAPI) RetryWebhookDeliveries(c *gin.Context) {
+	count, err := api.service.RetryWebhookDeliveries()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Set("data", count)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"blog-backend/common/config"
+	"blog-backend/common/export"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// 用法：go run ./api/batch/cmd/export -o blog-export.zip
+func main() {
+	output := flag.String("o", fmt.Sprintf("blog-export-%s.zip", time.Now().Format("20060102")), "輸出的 zip 檔案路徑")
+	flag.Parse()
+
+	db := config.InitDB()
+
+	f, err := os.Create(*output)
+	if err != nil {
+		log.Fatalf("建立檔案失敗: %v", err)
+	}
+	defer f.Close()
+
+	fmt.Println("🚀 開始匯出部落格內容")
+	summary, err := export.WriteArchive(context.Background(), db.DB, f)
+	if err != nil {
+		f.Close()
+		os.Remove(*output)
+		log.Fatalf("匯出失敗: %v", err)
+	}
+
+	for _, warning := range summary.Warnings {
+		fmt.Printf("⚠️ %s\n", warning)
+	}
+	fmt.Printf("✅ 匯出完成：文章 %d 篇、圖片 %d 張 → %s\n", summary.Posts, summary.Images, *output)
+}
@@ -1 +1,12 @@
 package batch
+
+type ScheduledPublishResultDto struct {
+	Published   []uint `json:"published"`   // 這次被發佈的文章 ID
+	Unpublished []uint `json:"unpublished"` // 這次被下架的文章 ID
+}
+
+type SanitizeContentResultDto struct {
+	Posts   []uint `json:"posts"`   // 內容有被清理的文章 ID
+	AboutMe bool   `json:"aboutMe"` // 關於我是否有被清理
+	Skipped []uint `json:"skipped"` // 內容無法解析而略過的文章 ID
+}
@@ -2,12 +2,19 @@ package batch
 
 import (
 	"blog-backend/common/entity"
+	"blog-backend/common/mailer"
 	"blog-backend/common/middleware"
+	"blog-backend/common/newsletter"
+	"blog-backend/common/search"
+	"blog-backend/common/utils"
+	"blog-backend/common/webhook"
 	"context"
+	"database/sql"
 	"fmt"
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -18,14 +25,24 @@ import (
 
 type BatchService interface {
 	CleanPendingImages() (int, error)
+	PublishScheduledPosts() (ScheduledPublishResultDto, error)
+	RebuildSearchIndex() (int, error)
+	PurgeExpiredTrash() (int, error)
+	BackfillPostMetadata() (int, error)
+	RebuildRelatedPosts() (int, error)
+	SanitizeStoredContent() (SanitizeContentResultDto, error)
+	RollupPostViews() (int, error)
+	SendPostNotifications() (int, error)
+	RetryWebhookDeliveries() (int, error)
 }
 
 type batchServiceImpl struct {
-	db *bun.DB
+	db     *bun.DB
+	mailer mailer.Mailer
 }
 
 func NewBatchService(db *bun.DB) BatchService {
-	return &batchServiceImpl{db: db}
+	return &batchServiceImpl{db: db, mailer: mailer.FromEnv()}
 }
 
 func (s *batchServiceImpl) CleanPendingImages() (int, error) {
@@ -97,6 +114,186 @@ func (s *batchServiceImpl) CleanPendingImages() (int, error) {
 	return deletedCount, nil
 }
 
+func (s *batchServiceImpl) PublishScheduledPosts() (ScheduledPublishResultDto, error) {
+	ctx := context.Background()
+
+	fmt.Println("🚀 開始執行排程發佈任務...")
+
+	result := ScheduledPublishResultDto{
+		Published:   []uint{},
+		Unpublished: []uint{},
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, middleware.ErrTransaction
+	}
+	defer tx.Rollback()
+
+	// 先處理發佈再處理下架：兩個時間都已經過去時，結果會是下架
+	err = tx.NewUpdate().
+		Model((*entity.Post)(nil)).
+		Set("is_published = TRUE, publish_at = NULL, needs_refresh = TRUE, updated_at = NOW(), version = version + 1").
+		Where("publish_at <= NOW()").
+		Where("is_deleted = FALSE").
+		Returning("id").
+		Scan(ctx, &result.Published)
+	if err != nil {
+		return result, middleware.WrapDBErr("更新排程發佈文章失敗", err)
+	}
+
+	err = tx.NewUpdate().
+		Model((*entity.Post)(nil)).
+		Set("is_published = FALSE, unpublish_at = NULL, needs_refresh = TRUE, updated_at = NOW(), version = version + 1").
+		Where("unpublish_at <= NOW()").
+		Where("is_deleted = FALSE").
+		Returning("id").
+		Scan(ctx, &result.Unpublished)
+	if err != nil {
+		return result, middleware.WrapDBErr("更新排程下架文章失敗", err)
+	}
+
+	// 排程發佈的文章也要通知電子報訂閱者（每篇只通知一次）
+	notifyIDs := []uint{}
+	for _, postID := range result.Published {
+		claimed, err := newsletter.ClaimPostNotification(ctx, tx, postID)
+		if err != nil {
+			return result, middleware.WrapDBErr("建立電子報通知失敗", err)
+		}
+		if claimed {
+			notifyIDs = append(notifyIDs, postID)
+		}
+	}
+
+	deliveryIDs, err := enqueueScheduledPostEvents(ctx, tx, result)
+	if err != nil {
+		return result, err
+	}
+
+	if len(result.Published) > 0 || len(result.Unpublished) > 0 {
+		if err := utils.BumpContentVersion(ctx, tx); err != nil {
+			return result, middleware.WrapDBErr("更新內容版本失敗", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, middleware.ErrTransaction
+	}
+
+	fmt.Printf("🔍 發佈 %d 篇、下架 %d 篇文章\n", len(result.Published), len(result.Unpublished))
+
+	// 傳送失敗的 webhook 留給 RetryWebhookDeliveries 重試
+	if _, err := webhook.Deliver(ctx, s.db, deliveryIDs); err != nil {
+		fmt.Printf("⚠️ webhook 傳送失敗（PublishScheduledPosts）：%v\n", err)
+	}
+
+	// 寄送失敗的通知留給 SendPostNotifications 重試
+	for _, postID := range notifyIDs {
+		if _, err := newsletter.SendPostNotification(ctx, s.db, s.mailer, postID); err != nil {
+			fmt.Printf("⚠️ 新文章通知失敗（PublishScheduledPosts）：文章 ID=%d，錯誤：%v\n", postID, err)
+		}
+	}
+
+	if len(result.Published) == 0 && len(result.Unpublished) == 0 {
+		fmt.Println("✅ 沒有到期的排程，結束任務")
+		return result, nil
+	}
+
+	// 不論這次異動幾篇，只清一次快取、部署一次
+	if err := utils.PurgeWorkerCacheAndDeployVercel(); err != nil {
+		fmt.Printf("⚠️ 部署失敗（PublishScheduledPosts）：%v\n", err)
+	}
+
+	fmt.Println("🎉 排程發佈任務完成")
+
+	return result, nil
+}
+
+func (s *batchServiceImpl) RebuildSearchIndex() (int, error) {
+	ctx := context.Background()
+
+	fmt.Println("🚀 開始重建搜尋索引...")
+
+	var posts []entity.Post
+	err := s.db.NewSelect().
+		Model(&posts).
+		Column("id", "title", "content").
+		Where("is_deleted = FALSE").
+		Scan(ctx)
+	if err != nil {
+		return 0, middleware.WrapDBErr("查詢文章失敗", err)
+	}
+	fmt.Printf("🔍 共 %d 篇文章需要建立索引\n", len(posts))
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, middleware.ErrTransaction
+	}
+	defer tx.Rollback()
+
+	// 已刪除的文章不應留在索引內
+	_, err = tx.NewDelete().
+		Model((*entity.PostSearchIndex)(nil)).
+		Where("post_id NOT IN (?)", tx.NewSelect().Model((*entity.Post)(nil)).Column("id").Where("is_deleted = FALSE")).
+		Exec(ctx)
+	if err != nil {
+		return 0, middleware.WrapDBErr("清除失效索引失敗", err)
+	}
+
+	for _, post := range posts {
+		if err := search.IndexPost(ctx, tx, post.ID, post.Title, post.Content); err != nil {
+			return 0, middleware.WrapDBErr(fmt.Sprintf("建立文章 %d 的索引失敗", post.ID), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, middleware.ErrTransaction
+	}
+
+	fmt.Printf("🎉 搜尋索引重建完成，共 %d 篇文章\n", len(posts))
+
+	return len(posts), nil
+}
+
+func (s *batchServiceImpl) PurgeExpiredTrash() (int, error) {
+	ctx := context.Background()
+
+	retention := utils.TrashRetention()
+	cutoff := time.Now().Add(-retention)
+	fmt.Printf("🚀 開始清理垃圾桶，永久刪除 %s 之前刪除的文章...\n", cutoff.Format(time.DateTime))
+
+	// 功能上線前刪除的文章沒有 deleted_at，以最後更新時間代替
+	var posts []entity.Post
+	err := s.db.NewSelect().
+		Model(&posts).
+		Column("id", "title").
+		Where("is_deleted = TRUE").
+		Where("COALESCE(deleted_at, updated_at) < ?", cutoff).
+		Scan(ctx)
+	if err != nil {
+		return 0, middleware.WrapDBErr("查詢垃圾桶文章失敗", err)
+	}
+	fmt.Printf("🔍 找到 %d 篇超過保留期限的文章\n", len(posts))
+
+	purgedCount := 0
+	for i, post := range posts {
+		fmt.Printf("👉 [%d/%d] 永久刪除文章 ID=%d（%s）\n", i+1, len(posts), post.ID, post.Title)
+
+		if err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			return utils.HardDeletePost(ctx, tx, post.ID)
+		}); err != nil {
+			fmt.Printf("❌ 永久刪除失敗: ID=%d，錯誤: %v\n", post.ID, err)
+			continue
+		}
+
+		purgedCount++
+	}
+
+	fmt.Printf("🎉 垃圾桶清理完成，共永久刪除 %d 篇文章\n", purgedCount)
+
+	return purgedCount, nil
+}
+
 // 建立 R2 client
 func newR2Client() (*s3.S3, error) {
 	endpoint := os.Getenv("R2_ENDPOINT")
@@ -141,3 +338,315 @@ func extractR2ObjectKey(imageURL string) string {
 
 	return strings.TrimPrefix(u.Path, "/")
 }
+
+// 重新計算所有文章儲存時產生的欄位（目錄、字數、閱讀時間、摘要），給功能上線前的舊文章或計算方式調整後使用
+func (s *batchServiceImpl) BackfillPostMetadata() (int, error) {
+	ctx := context.Background()
+
+	fmt.Println("🚀 開始回填文章目錄、字數、閱讀時間與摘要...")
+
+	var posts []entity.Post
+	err := s.db.NewSelect().
+		Model(&posts).
+		Column("id", "content", "excerpt").
+		Scan(ctx)
+	if err != nil {
+		return 0, middleware.WrapDBErr("查詢文章失敗", err)
+	}
+	fmt.Printf("🔍 共 %d 篇文章需要回填\n", len(posts))
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, middleware.ErrTransaction
+	}
+	defer tx.Rollback()
+
+	for _, post := range posts {
+		utils.ApplyPostStats(&post)
+
+		// 只是補上衍生欄位，不更動 updated_at
+		_, err := tx.NewUpdate().
+			Model(&post).
+			Column("toc", "word_count", "reading_minutes", "summary").
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return 0, middleware.WrapDBErr(fmt.Sprintf("回填文章 %d 失敗", post.ID), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, middleware.ErrTransaction
+	}
+
+	fmt.Printf("🎉 文章資料回填完成，共 %d 篇文章\n", len(posts))
+
+	return len(posts), nil
+}
+
+func (s *batchServiceImpl) RebuildRelatedPosts() (int, error) {
+	ctx := context.Background()
+
+	fmt.Println("🚀 開始重新計算相關文章...")
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, middleware.ErrTransaction
+	}
+	defer tx.Rollback()
+
+	count, err := search.RebuildRelatedPosts(ctx, tx)
+	if err != nil {
+		return 0, middleware.WrapDBErr("重新計算相關文章失敗", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, middleware.ErrTransaction
+	}
+
+	fmt.Printf("🎉 相關文章計算完成，共 %d 篇文章\n", count)
+
+	return count, nil
+}
+
+// 用目前的 HTML 白名單重新清理已經存入的文章與關於我（清理規則調整後、或功能上線前的舊資料使用）
+func (s *batchServiceImpl) SanitizeStoredContent() (SanitizeContentResultDto, error) {
+	ctx := context.Background()
+
+	fmt.Println("🚀 開始清理文章與關於我的 HTML...")
+
+	result := SanitizeContentResultDto{
+		Posts:   []uint{},
+		Skipped: []uint{},
+	}
+
+	// 垃圾桶中的文章也要清理，還原後才不會帶回不安全的內容
+	var posts []entity.Post
+	err := s.db.NewSelect().
+		Model(&posts).
+		Column("id", "title", "content", "excerpt", "is_published", "is_deleted", "version").
+		Scan(ctx)
+	if err != nil {
+		return result, middleware.WrapDBErr("查詢文章失敗", err)
+	}
+	fmt.Printf("🔍 共 %d 篇文章需要檢查\n", len(posts))
+
+	var abouts []entity.AboutMe
+	err = s.db.NewSelect().
+		Model(&abouts).
+		Scan(ctx)
+	if err != nil {
+		return result, middleware.WrapDBErr("查詢關於我失敗", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, middleware.ErrTransaction
+	}
+	defer tx.Rollback()
+
+	needsDeploy := false
+	for _, post := range posts {
+		content, changed, err := utils.SanitizeEditorJS(post.Content)
+		if err != nil {
+			fmt.Printf("⚠️ 文章 %d 的內容無法解析，略過：%v\n", post.ID, err)
+			result.Skipped = append(result.Skipped, post.ID)
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		post.Content = content
+		utils.ApplyPostStats(&post)
+		post.Version++
+
+		// 內容只是清理過，不更動 updated_at；版本號要加 1，讓正在編輯的分頁重新載入
+		columns := []string{"content", "toc", "word_count", "reading_minutes", "summary", "version"}
+		if post.IsPublished {
+			post.NeedsRefresh = true
+			columns = append(columns, "needs_refresh")
+		}
+		_, err = tx.NewUpdate().
+			Model(&post).
+			Column(columns...).
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return result, middleware.WrapDBErr(fmt.Sprintf("更新文章 %d 失敗", post.ID), err)
+		}
+		if !post.IsDeleted {
+			if err := search.IndexPost(ctx, tx, post.ID, post.Title, post.Content); err != nil {
+				return result, middleware.WrapDBErr(fmt.Sprintf("更新文章 %d 的索引失敗", post.ID), err)
+			}
+		}
+
+		fmt.Printf("✅ 已清理文章 ID=%d（%s）\n", post.ID, post.Title)
+		result.Posts = append(result.Posts, post.ID)
+		if post.IsPublished && !post.IsDeleted {
+			needsDeploy = true
+		}
+	}
+
+	for _, about := range abouts {
+		content, changed, err := utils.SanitizeEditorJS(about.HtmlContent)
+		if err != nil {
+			fmt.Printf("⚠️ 關於我的內容無法解析，略過：%v\n", err)
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		about.HtmlContent = content
+		about.Version++
+		_, err = tx.NewUpdate().
+			Model(&about).
+			Column("html_content", "version").
+			WherePK().
+			Exec(ctx)
+		if err != nil {
+			return result, middleware.WrapDBErr("更新關於我失敗", err)
+		}
+
+		fmt.Println("✅ 已清理關於我")
+		result.AboutMe = true
+		needsDeploy = true
+	}
+
+	if needsDeploy {
+		if err := utils.BumpContentVersion(ctx, tx); err != nil {
+			return result, middleware.WrapDBErr("更新內容版本失敗", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, middleware.ErrTransaction
+	}
+
+	fmt.Printf("🔍 清理 %d 篇文章，略過 %d 篇\n", len(result.Posts), len(result.Skipped))
+
+	if needsDeploy {
+		if err := utils.PurgeWorkerCacheAndDeployVercel(); err != nil {
+			fmt.Printf("⚠️ 部署失敗（SanitizeStoredContent）：%v\n", err)
+		}
+	}
+
+	fmt.Println("🎉 HTML 清理任務完成")
+
+	return result, nil
+}
+
+// 把 post_views 彙總成每篇文章每天的瀏覽數
+// 從最後彙總的那一天（可能只彙總了一部分）重新計算到今天，重複執行結果相同
+func (s *batchServiceImpl) RollupPostViews() (int, error) {
+	ctx := context.Background()
+
+	fmt.Println("🚀 開始彙總文章瀏覽數...")
+
+	var lastDay sql.NullTime
+	err := s.db.NewSelect().
+		Model((*entity.PostViewDaily)(nil)).
+		ColumnExpr("MAX(day)").
+		Scan(ctx, &lastDay)
+	if err != nil {
+		return 0, middleware.WrapDBErr("查詢最後彙總日期失敗", err)
+	}
+
+	views := s.db.NewSelect().
+		Model((*entity.PostView)(nil)).
+		Column("post_id").
+		ColumnExpr("viewed_at::date AS day").
+		ColumnExpr("COUNT(*) AS views").
+		GroupExpr("post_id, viewed_at::date")
+	if lastDay.Valid {
+		fmt.Printf("🔍 從 %s 開始彙總\n", lastDay.Time.Format(time.DateOnly))
+		views = views.Where("viewed_at >= ?::date", lastDay.Time.Format(time.DateOnly))
+	}
+
+	res, err := s.db.NewRaw(
+		"INSERT INTO post_view_daily (post_id, day, views) ? ON CONFLICT (post_id, day) DO UPDATE SET views = EXCLUDED.views",
+		views,
+	).Exec(ctx)
+	if err != nil {
+		return 0, middleware.WrapDBErr("彙總文章瀏覽數失敗", err)
+	}
+	count, _ := res.RowsAffected()
+
+	fmt.Printf("🎉 文章瀏覽數彙總完成，共更新 %d 筆每日資料\n", count)
+
+	return int(count), nil
+}
+
+// 排程發佈的文章送出 post.updated + post.published，下架的送出 post.updated
+func enqueueScheduledPostEvents(ctx context.Context, tx bun.Tx, result ScheduledPublishResultDto) ([]int64, error) {
+	ids := append(append([]uint{}, result.Published...), result.Unpublished...)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var posts []entity.Post
+	err := tx.NewSelect().
+		Model(&posts).
+		Column("id", "title", "slug", "category_id", "is_published", "is_deleted", "summary", "updated_at").
+		Where("id IN (?)", bun.In(ids)).
+		OrderExpr("id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, middleware.WrapDBErr("查詢排程文章失敗", err)
+	}
+
+	published := make(map[uint]bool, len(result.Published))
+	for _, id := range result.Published {
+		published[id] = true
+	}
+
+	deliveryIDs := []int64{}
+	for _, post := range posts {
+		events := []string{webhook.EventPostUpdated}
+		// 同時到期的發佈與下架，結果是下架
+		if published[post.ID] && post.IsPublished {
+			events = append(events, webhook.EventPostPublished)
+		}
+		for _, event := range events {
+			created, err := webhook.Enqueue(ctx, tx, event, webhook.NewPostData(post))
+			if err != nil {
+				return nil, middleware.WrapDBErr("建立 webhook 傳送紀錄失敗", err)
+			}
+			deliveryIDs = append(deliveryIDs, created...)
+		}
+	}
+	return deliveryIDs, nil
+}
+
+// SendPostNotifications 寄出已發佈但還沒寄出的新文章通知（例如發佈後寄信前程式中斷），回傳寄出通知的文章數
+func (s *batchServiceImpl) SendPostNotifications() (int, error) {
+	ctx := context.Background()
+
+	fmt.Println("🚀 開始寄送未完成的新文章通知...")
+
+	count, err := newsletter.SendPendingNotifications(ctx, s.db, s.mailer)
+	if err != nil {
+		return count, middleware.Newf(middleware.ErrExternalService.Code, "寄送新文章通知失敗：%v", err)
+	}
+
+	fmt.Printf("🎉 新文章通知寄送完成，共 %d 篇文章\n", count)
+
+	return count, nil
+}
+
+// RetryWebhookDeliveries 重送到了重試時間的 webhook，回傳這次成功送達的數量
+func (s *batchServiceImpl) RetryWebhookDeliveries() (int, error) {
+	ctx := context.Background()
+
+	fmt.Println("🚀 開始重送 webhook...")
+
+	count, err := webhook.DeliverDue(ctx, s.db)
+	if err != nil {
+		return count, middleware.WrapDBErr("重送 webhook 失敗", err)
+	}
+
+	fmt.Printf("🎉 webhook 重送完成，共成功送達 %d 筆\n", count)
+
+	return count, nil
+}
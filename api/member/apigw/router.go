@@ -74,6 +74,16 @@ func RegisterRoutes(r *gin.Engine) {
 		// ✳️ 傳回原始結果
 		c.Status(resp.StatusCode)
 		c.Header("Content-Type", resp.Header.Get("Content-Type"))
+		// 快取設定也要帶回去（例如預覽文章的 no-store），否則 Worker 會照預設快取
+		if cacheControl := resp.Header.Get("Cache-Control"); cacheControl != "" {
+			c.Header("Cache-Control", cacheControl)
+		}
+		// feed 的條件式請求（If-None-Match）需要 ETag，Last-Modified 供閱讀器參考
+		for _, key := range []string{"ETag", "Last-Modified"} {
+			if value := resp.Header.Get(key); value != "" {
+				c.Header(key, value)
+			}
+		}
 		io.Copy(c.Writer, resp.Body)
 	})
 }
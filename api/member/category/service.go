@@ -7,6 +7,8 @@ import (
 
 	"blog-backend/common/entity"
 	"blog-backend/common/middleware"
+	"blog-backend/common/model"
+	"blog-backend/common/utils"
 
 	"github.com/uptrace/bun"
 )
@@ -80,18 +82,49 @@ func (s *categoryServiceImpl) GetCategoryTree() ([]*CategoryDto, error) {
 }
 
 func (s *categoryServiceImpl) GetCategoryBySlug(slug string) (entity.Category, error) {
+	ctx := context.Background()
+
 	var category entity.Category
 	err := s.db.NewSelect().
 		Model(&category).
 		Where("category.slug = ?", slug).
 		Limit(1).
-		Scan(context.Background())
+		Scan(ctx)
 
 	if errors.Is(err, sql.ErrNoRows) {
-		return entity.Category{}, middleware.ErrNotFound
+		return entity.Category{}, s.findMovedCategory(ctx, slug)
 	} else if err != nil {
 		return entity.Category{}, middleware.WrapDBErr("查詢分類失敗", err)
 	}
 
 	return category, nil
 }
+
+// 找不到 slug 時查 slug 歷史：分類改過 slug 就回傳 ErrMoved（附上目前的 slug），否則回傳 ErrNotFound
+func (s *categoryServiceImpl) findMovedCategory(ctx context.Context, slug string) error {
+	categoryID, found, err := utils.FindSlugRedirect(ctx, s.db, utils.SlugEntityCategory, slug)
+	if err != nil {
+		return middleware.WrapDBErr("查詢分類 slug 歷史失敗", err)
+	}
+	if !found {
+		return middleware.ErrNotFound
+	}
+
+	var category entity.Category
+	err = s.db.NewSelect().
+		Model(&category).
+		Column("slug").
+		Where("category.id = ?", categoryID).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return middleware.ErrNotFound
+	} else if err != nil {
+		return middleware.WrapDBErr("查詢分類失敗", err)
+	}
+
+	return middleware.ErrMoved.WithData(model.MovedData{
+		Type:          utils.SlugEntityCategory,
+		CanonicalSlug: category.Slug,
+	})
+}
@@ -1,7 +1,12 @@
 package post
 
 import (
+	"net/http"
+	"strings"
+
+	"blog-backend/common/feed"
 	"blog-backend/common/middleware"
+	"blog-backend/common/seo"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,8 +25,23 @@ func (api *PostAPI) RegisterRoutes(r *gin.Engine) {
 	apiGroup := r.Group("/api/post")
 	{
 		apiGroup.GET("", api.GetPostList)
+		apiGroup.GET("/search", api.SearchPosts)
+		apiGroup.GET("/popular", api.GetPopularPosts)
+		apiGroup.GET("/trending", api.GetTrendingPosts)
+		apiGroup.GET("/feed", api.GetFeed)
+		apiGroup.GET("/sitemap.xml", api.GetSitemap)
+		apiGroup.GET("/sitemap/:page", api.GetSitemapPage)
+		apiGroup.POST("/newsletter/subscribe", api.Subscribe)
+		apiGroup.POST("/newsletter/confirm", api.ConfirmSubscription)
+		apiGroup.POST("/newsletter/unsubscribe", api.Unsubscribe)
 		apiGroup.GET("/:slug", api.GetPostBySlug)
+		apiGroup.GET("/:slug/related", api.GetRelatedPosts)
+		apiGroup.POST("/:slug/view", api.RecordPostView)
+		apiGroup.GET("/:slug/comments", api.GetComments)
+		apiGroup.POST("/:slug/comments", api.SubmitComment)
 		apiGroup.GET("/category/:slug", api.GetPostsByCategory)
+		apiGroup.GET("/category/:slug/feed", api.GetCategoryFeed)
+		apiGroup.GET("/tag/:slug", api.GetPostsByTag)
 		apiGroup.GET("/about", api.GetAboutMe)
 		apiGroup.POST("/randomCategoryPost", api.GetRandomPostsByCategory)
 	}
@@ -42,10 +62,35 @@ func (api *PostAPI) GetPostList(c *gin.Context) {
 	c.Set("data", result)
 }
 
-// 取得單一文章
+// 全文搜尋文章
+func (api *PostAPI) SearchPosts(c *gin.Context) {
+	var req SearchPostDto
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+	result, err := api.service.SearchPosts(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 取得單一文章（?format=editorjs|html|text，帶 ?preview=<token> 可預覽未發佈的文章）
 func (api *PostAPI) GetPostBySlug(c *gin.Context) {
 	slug := c.Param("slug")
-	post, err := api.service.GetPostBySlug(slug)
+
+	var req GetPostDto
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+	if req.Preview != "" {
+		// 預覽內容（包含錯誤回應）都不能被 Worker 或瀏覽器快取
+		c.Header("Cache-Control", "no-store, private")
+	}
+	post, err := api.service.GetPostBySlug(slug, req)
 	if err != nil {
 		c.Error(err)
 		return
@@ -70,9 +115,31 @@ func (api *PostAPI) GetPostsByCategory(c *gin.Context) {
 	c.Set("data", result)
 }
 
-// 取得關於我內容
+// 取得標籤文章
+func (api *PostAPI) GetPostsByTag(c *gin.Context) {
+	slug := c.Param("slug")
+
+	var req GetPostListDto
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+	result, err := api.service.GetPostsByTag(slug, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 取得關於我內容（?format=editorjs|html|text）
 func (api *PostAPI) GetAboutMe(c *gin.Context) {
-	about, err := api.service.GetAboutMe()
+	var req ContentFormatDto
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+	about, err := api.service.GetAboutMe(req.Format)
 	if err != nil {
 		c.Error(err)
 		return
@@ -80,7 +147,169 @@ func (api *PostAPI) GetAboutMe(c *gin.Context) {
 	c.Set("data", about)
 }
 
-// 取得分類底下隨機六篇文章
+// 取得相關文章（依相關程度排序，結果固定）
+func (api *PostAPI) GetRelatedPosts(c *gin.Context) {
+	slug := c.Param("slug")
+
+	var req GetRelatedPostsDto
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+	posts, err := api.service.GetRelatedPosts(slug, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", posts)
+}
+
+// 記錄文章瀏覽（前台 Worker 在文章頁呼叫，機器人與短時間內的重複瀏覽不計入）
+func (api *PostAPI) RecordPostView(c *gin.Context) {
+	slug := c.Param("slug")
+
+	var req RecordPostViewDto
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(middleware.ErrBadRequest)
+			return
+		}
+	}
+	if req.ClientIP == "" {
+		req.ClientIP = clientIP(c)
+	}
+	if req.UserAgent == "" {
+		req.UserAgent = c.GetHeader("User-Agent")
+	}
+
+	c.Header("Cache-Control", "no-store")
+	result, err := api.service.RecordPostView(slug, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 取得文章已核准的留言（巢狀回覆）
+func (api *PostAPI) GetComments(c *gin.Context) {
+	slug := c.Param("slug")
+
+	comments, err := api.service.GetComments(slug)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", comments)
+}
+
+// 送出留言（審核後才會顯示）
+func (api *PostAPI) SubmitComment(c *gin.Context) {
+	slug := c.Param("slug")
+
+	var req SubmitCommentDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+	if req.ClientIP == "" {
+		req.ClientIP = clientIP(c)
+	}
+	if req.UserAgent == "" {
+		req.UserAgent = c.GetHeader("User-Agent")
+	}
+
+	c.Header("Cache-Control", "no-store")
+	result, err := api.service.SubmitComment(slug, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 訂閱電子報（寄出確認信）
+func (api *PostAPI) Subscribe(c *gin.Context) {
+	var req SubscribeDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	result, err := api.service.Subscribe(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 確認訂閱
+func (api *PostAPI) ConfirmSubscription(c *gin.Context) {
+	token, ok := newsletterToken(c)
+	if !ok {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	result, err := api.service.ConfirmSubscription(token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 取消訂閱（信箱的一鍵取消訂閱會以 form 送出 List-Unsubscribe=One-Click，token 在 query string）
+func (api *PostAPI) Unsubscribe(c *gin.Context) {
+	token, ok := newsletterToken(c)
+	if !ok {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	result, err := api.service.Unsubscribe(token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", result)
+}
+
+// 取得熱門文章（所有時間的瀏覽數）
+func (api *PostAPI) GetPopularPosts(c *gin.Context) {
+	var req GetPopularPostsDto
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+	posts, err := api.service.GetPopularPosts(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", posts)
+}
+
+// 取得熱門趨勢文章（最近的瀏覽權重較高）
+func (api *PostAPI) GetTrendingPosts(c *gin.Context) {
+	var req GetPopularPostsDto
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+	posts, err := api.service.GetTrendingPosts(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Set("data", posts)
+}
+
+// 取得分類底下隨機六篇文章（新版前台改用 GetRelatedPosts）
 func (api *PostAPI) GetRandomPostsByCategory(c *gin.Context) {
 	var dto GetRandomPostsByCategoryDto
 	if err := c.ShouldBindJSON(&dto); err != nil {
@@ -96,3 +325,117 @@ func (api *PostAPI) GetRandomPostsByCategory(c *gin.Context) {
 
 	c.Set("data", posts)
 }
+
+// 全站 feed（?format=rss|atom|json&content=summary|full）
+func (api *PostAPI) GetFeed(c *gin.Context) {
+	var req GetFeedDto
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+	req.SelfURL = seo.SiteBaseURL() + c.Request.URL.RequestURI()
+	result, err := api.service.GetFeed(req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	writeFeed(c, result, req)
+}
+
+// 分類 feed（包含子分類的文章），參數同全站 feed
+func (api *PostAPI) GetCategoryFeed(c *gin.Context) {
+	slug := c.Param("slug")
+
+	var req GetFeedDto
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(middleware.ErrBadRequest)
+		return
+	}
+	req.SelfURL = seo.SiteBaseURL() + c.Request.URL.RequestURI()
+	result, err := api.service.GetCategoryFeed(slug, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	writeFeed(c, result, req)
+}
+
+// sitemap.xml（網址超過 50,000 個時為 sitemap index）
+func (api *PostAPI) GetSitemap(c *gin.Context) {
+	body, err := api.service.GetSitemap()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", body)
+}
+
+// sitemap index 中的第 n 個 sitemap 檔
+func (api *PostAPI) GetSitemapPage(c *gin.Context) {
+	body, err := api.service.GetSitemapPage(c.Param("page"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", body)
+}
+
+// 輸出 feed，帶 ETag 與 Last-Modified（最新文章的 UpdatedAt）；ETag 相同時回傳 304
+func writeFeed(c *gin.Context, f feed.Feed, req GetFeedDto) {
+	format := req.Format
+	if format == "" {
+		format = feed.FormatRSS
+	}
+
+	etag := feed.ETag(f, format, req.Content == "full")
+	c.Header("ETag", etag)
+	if !f.Updated.IsZero() {
+		c.Header("Last-Modified", f.Updated.UTC().Format(http.TimeFormat))
+	}
+	if feedNotModified(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	body, err := feed.Render(f, format)
+	if err != nil {
+		c.Error(middleware.Newf(middleware.ErrDataError.Code, "產生 feed 失敗：%v", err))
+		return
+	}
+	c.Data(http.StatusOK, feed.ContentType(format), body)
+}
+
+// 只依 If-None-Match 判斷：最新的文章被下架或刪除時 Last-Modified 會往回走，
+// 用 If-Modified-Since 判斷會一直回 304；ETag 包含文章清單，不會有這個問題
+func feedNotModified(c *gin.Context, etag string) bool {
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || (candidate != "" && strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// 電子報 token：query string 優先，沒有時讀 JSON body
+func newsletterToken(c *gin.Context) (string, bool) {
+	if token := strings.TrimSpace(c.Query("token")); token != "" {
+		return token, true
+	}
+	if c.ContentType() != "application/json" {
+		return "", false
+	}
+	var req NewsletterTokenDto
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(req.Token), true
+}
+
+// 訪客 IP：經過 Cloudflare 時以 CF-Connecting-IP 為準
+func clientIP(c *gin.Context) string {
+	if ip := strings.TrimSpace(c.GetHeader("CF-Connecting-IP")); ip != "" {
+		return ip
+	}
+	return c.ClientIP()
+}
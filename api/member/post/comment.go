@@ -0,0 +1,197 @@
+package post
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/middleware"
+	"blog-backend/common/spam"
+)
+
+// 每個 IP 在一段時間內可以送出的留言數（COMMENT_RATE_LIMIT、COMMENT_RATE_WINDOW_MINUTES 可調整）
+const (
+	defaultCommentRateLimit     = 3
+	defaultCommentWindowMinutes = 10
+)
+
+// 取得文章已核准的留言，回覆依時間排在各自的留言底下
+func (s *postServiceImpl) GetComments(slug string) ([]CommentDto, error) {
+	ctx := context.Background()
+
+	postID, err := s.findVisiblePostID(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []entity.Comment
+	err = s.db.NewSelect().
+		Model(&comments).
+		Column("id", "parent_id", "author_name", "content", "created_at").
+		Where("post_id = ?", postID).
+		Where("status = ?", entity.CommentStatusApproved).
+		OrderExpr("created_at ASC, id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, middleware.ErrDB
+	}
+
+	return buildCommentTree(comments), nil
+}
+
+// 送出留言：蜜罐欄位有值時假裝成功但不儲存；超過頻率限制回傳 ErrTooManyRequests；
+// 通過垃圾留言評分的留言進入審核佇列，分數過高的直接標記為垃圾留言（回應相同，不讓發送者知道結果）
+func (s *postServiceImpl) SubmitComment(slug string, req SubmitCommentDto) (SubmitCommentResultDto, error) {
+	ctx := context.Background()
+	result := SubmitCommentResultDto{Status: entity.CommentStatusPending}
+
+	if strings.TrimSpace(req.Website) != "" {
+		return result, nil
+	}
+
+	name := strings.TrimSpace(req.AuthorName)
+	content := strings.TrimSpace(req.Content)
+	if name == "" || content == "" {
+		return SubmitCommentResultDto{}, middleware.Newf(middleware.ErrValidation.Code, "名稱與留言內容不能為空")
+	}
+
+	postID, err := s.findVisiblePostID(ctx, slug)
+	if err != nil {
+		return SubmitCommentResultDto{}, err
+	}
+
+	if req.ParentID != nil {
+		// 只能回覆同一篇文章中已核准的留言
+		exists, err := s.db.NewSelect().
+			Model((*entity.Comment)(nil)).
+			Where("id = ?", *req.ParentID).
+			Where("post_id = ?", postID).
+			Where("status = ?", entity.CommentStatusApproved).
+			Exists(ctx)
+		if err != nil {
+			return SubmitCommentResultDto{}, middleware.ErrDB
+		}
+		if !exists {
+			return SubmitCommentResultDto{}, middleware.Newf(middleware.ErrNotFound.Code, "找不到要回覆的留言：%d", *req.ParentID)
+		}
+	}
+
+	ipHash := visitorHash(strings.TrimSpace(req.ClientIP))
+	limit, window := commentRateLimit()
+	recent, err := s.db.NewSelect().
+		Model((*entity.Comment)(nil)).
+		Where("ip_hash = ?", ipHash).
+		Where("created_at > ?", time.Now().Add(-window)).
+		Count(ctx)
+	if err != nil {
+		return SubmitCommentResultDto{}, middleware.ErrDB
+	}
+	if recent >= limit {
+		return SubmitCommentResultDto{}, middleware.Newf(middleware.ErrTooManyRequests.Code, "留言太頻繁，請在 %d 分鐘後再試", int(window.Minutes()))
+	}
+
+	comment := entity.Comment{
+		PostID:      postID,
+		ParentID:    req.ParentID,
+		AuthorName:  name,
+		AuthorEmail: strings.ToLower(strings.TrimSpace(req.AuthorEmail)),
+		Content:     content,
+		Status:      entity.CommentStatusPending,
+		IPHash:      ipHash,
+		UserAgent:   req.UserAgent,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	score, err := s.spamScorer.Score(ctx, spam.Input{
+		AuthorName:  comment.AuthorName,
+		AuthorEmail: comment.AuthorEmail,
+		Content:     comment.Content,
+		UserAgent:   comment.UserAgent,
+	})
+	if err != nil {
+		// 評分服務失敗時不擋留言，反正都要人工審核
+		fmt.Printf("⚠️ 垃圾留言評分失敗：%v\n", err)
+	}
+	comment.SpamScore = score
+	if score >= spam.Threshold {
+		comment.Status = entity.CommentStatusSpam
+	}
+
+	if _, err := s.db.NewInsert().Model(&comment).Exec(ctx); err != nil {
+		return SubmitCommentResultDto{}, middleware.ErrDB
+	}
+
+	return result, nil
+}
+
+// 找出前台可見文章的 ID，找不到時檢查是否改過 slug
+func (s *postServiceImpl) findVisiblePostID(ctx context.Context, slug string) (uint, error) {
+	var post entity.Post
+	err := s.db.NewSelect().
+		Model(&post).
+		Column("post.id").
+		Where("post.slug = ?", slug).
+		Apply(visiblePosts).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, s.findMovedPost(ctx, slug)
+	} else if err != nil {
+		return 0, middleware.ErrDB
+	}
+	return post.ID, nil
+}
+
+// 依 ParentID 組成巢狀結構，回覆的對象沒有核准（或已刪除）時整串不顯示
+func buildCommentTree(comments []entity.Comment) []CommentDto {
+	children := map[uint][]entity.Comment{}
+	roots := []entity.Comment{}
+	for _, comment := range comments {
+		if comment.ParentID == nil {
+			roots = append(roots, comment)
+			continue
+		}
+		children[*comment.ParentID] = append(children[*comment.ParentID], comment)
+	}
+
+	var build func(comment entity.Comment) CommentDto
+	build = func(comment entity.Comment) CommentDto {
+		dto := CommentDto{
+			ID:         comment.ID,
+			ParentID:   comment.ParentID,
+			AuthorName: comment.AuthorName,
+			Content:    comment.Content,
+			CreatedAt:  comment.CreatedAt,
+			Replies:    []CommentDto{},
+		}
+		for _, child := range children[comment.ID] {
+			dto.Replies = append(dto.Replies, build(child))
+		}
+		return dto
+	}
+
+	result := []CommentDto{}
+	for _, root := range roots {
+		result = append(result, build(root))
+	}
+	return result
+}
+
+func commentRateLimit() (int, time.Duration) {
+	limit, err := strconv.Atoi(os.Getenv("COMMENT_RATE_LIMIT"))
+	if err != nil || limit <= 0 {
+		limit = defaultCommentRateLimit
+	}
+	minutes, err := strconv.Atoi(os.Getenv("COMMENT_RATE_WINDOW_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = defaultCommentWindowMinutes
+	}
+	return limit, time.Duration(minutes) * time.Minute
+}
@@ -1,36 +1,144 @@
 package post
 
-import "time"
+import (
+	"time"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/seo"
+)
 
 type GetPostListDto struct {
 	Page  int `form:"page"`
 	Limit int `form:"limit"`
 }
 
-type PostListDto struct {
+type ContentFormatDto struct {
+	Format string `form:"format" binding:"omitempty,oneof=editorjs html text"` // 內容格式，預設 editorjs
+}
+
+type GetPostDto struct {
+	Format  string `form:"format" binding:"omitempty,oneof=editorjs html text"` // 內容格式，預設 editorjs
+	Preview string `form:"preview"`                                             // 後台產生的預覽 token，可讀取未發佈的文章
+}
+
+type SearchPostDto struct {
+	Q     string `form:"q" binding:"required"`
+	Page  int    `form:"page"`
+	Limit int    `form:"limit"`
+}
+
+type SearchResultDto struct {
 	Slug          string    `json:"slug"`
 	Title         string    `json:"title"`
 	Summary       string    `json:"summary"`
+	Snippet       string    `json:"snippet"` // 命中位置前後的內文，命中的詞以 <mark> 標示（已 escape）
 	CoverImageUrl string    `json:"coverImageUrl"`
 	CreatedAt     time.Time `json:"createdAt"`
+	Score         float64   `json:"score"`
+}
+
+type PostListDto struct {
+	Slug           string    `json:"slug"`
+	Title          string    `json:"title"`
+	Summary        string    `json:"summary"`
+	CoverImageUrl  string    `json:"coverImageUrl"`
+	ReadingMinutes int       `json:"readingMinutes"` // 預估閱讀分鐘數
+	CreatedAt      time.Time `json:"createdAt"`
 }
 
 type PostDto struct {
-	Title         string    `json:"title"`
-	Summary       string    `json:"summary"`
-	Content       string    `json:"content"`
-	CategoryID    uint      `json:"categoryId"`
-	CoverImageUrl string    `json:"coverImageUrl"`
-	CreatedAt     time.Time `json:"createdAt"`
+	Title          string           `json:"title"`
+	Summary        string           `json:"summary"`
+	Content        string           `json:"content"`
+	Format         string           `json:"format"` // content 的格式：editorjs、html 或 text
+	CategoryID     uint             `json:"categoryId"`
+	CoverImageUrl  string           `json:"coverImageUrl"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	Tags           []TagDto         `json:"tags"`
+	Toc            []entity.TocItem `json:"toc"`            // 由標題產生的巢狀目錄，id 對應 HTML 輸出的 header id
+	WordCount      int              `json:"wordCount"`      // 字數
+	ReadingMinutes int              `json:"readingMinutes"` // 預估閱讀分鐘數
+	Preview        bool             `json:"preview"`        // 以預覽連結讀取（文章可能尚未發佈）
+	Seo            seo.Meta         `json:"seo"`            // 已套用預設值的 SEO 資料
+	JsonLd         []interface{}    `json:"jsonLd"`         // BlogPosting 與 BreadcrumbList 的 JSON-LD
+}
+
+type TagDto struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
 }
 
 type AboutMeDto struct {
 	ID        uint      `json:"id"`
 	Content   string    `json:"content"`
+	Format    string    `json:"format"` // content 的格式：editorjs、html 或 text
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+type GetRelatedPostsDto struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=12"` // 回傳筆數，預設 6
+}
+
+type GetPopularPostsDto struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=20"` // 回傳筆數，預設 6
+}
+
+// 前台 Worker 代替訪客呼叫時，可以在 body 帶入訪客的 IP 與 User-Agent（沒有帶時使用請求本身的 header）
+type RecordPostViewDto struct {
+	ClientIP  string `json:"clientIp"`
+	UserAgent string `json:"userAgent"`
+}
+
+type RecordPostViewResultDto struct {
+	Counted bool   `json:"counted"`          // 是否計入瀏覽數
+	Reason  string `json:"reason,omitempty"` // 沒有計入的原因：bot、duplicate
+}
+
+type SubmitCommentDto struct {
+	ParentID    *uint  `json:"parentId"`                                     // 回覆的留言 ID，最上層留言不用帶
+	AuthorName  string `json:"authorName" binding:"required,max=50"`         // 顯示名稱
+	AuthorEmail string `json:"authorEmail" binding:"required,email,max=254"` // 不會公開
+	Content     string `json:"content" binding:"required,max=5000"`          // 純文字
+	Website     string `json:"website"`                                      // 蜜罐欄位：前台隱藏，一般讀者不會填寫
+	ClientIP    string `json:"clientIp"`                                     // 前台 Worker 帶入的訪客 IP，沒有帶時使用請求本身的 IP
+	UserAgent   string `json:"userAgent"`                                    // 前台 Worker 帶入的訪客 User-Agent，沒有帶時使用請求本身的 header
+}
+
+type SubmitCommentResultDto struct {
+	Status string `json:"status"` // 一律回傳 pending（等待審核），不讓發送者知道是否被判定為垃圾留言
+}
+
+type CommentDto struct {
+	ID         uint         `json:"id"`
+	ParentID   *uint        `json:"parentId"`
+	AuthorName string       `json:"authorName"`
+	Content    string       `json:"content"` // 純文字，前台需自行 escape
+	CreatedAt  time.Time    `json:"createdAt"`
+	Replies    []CommentDto `json:"replies"`
+}
+
+type SubscribeDto struct {
+	Email   string `json:"email" binding:"required,email,max=254"`
+	Website string `json:"website"` // 蜜罐欄位：前台隱藏，一般讀者不會填寫
+}
+
+type NewsletterTokenDto struct {
+	Token string `json:"token"` // 確認信或通知信連結中的 token，也可以放在 query string
+}
+
+type SubscriptionResultDto struct {
+	Email  string `json:"email,omitempty"` // 確認、取消訂閱時回傳
+	Status string `json:"status"`          // pending、confirmed 或 unsubscribed
+}
+
 type GetRandomPostsByCategoryDto struct {
 	CategoryID uint   `json:"categoryId"`
 	Slug       string `json:"slug"`
 }
+
+type GetFeedDto struct {
+	Format  string `form:"format" binding:"omitempty,oneof=rss atom json"` // rss（預設）、atom 或 json（JSON Feed）
+	Content string `form:"content" binding:"omitempty,oneof=summary full"` // summary（預設）只輸出摘要，full 輸出全文 HTML
+	Limit   int    `form:"limit" binding:"omitempty,min=1,max=50"`         // 文章數，預設 20
+	SelfURL string `form:"-"`                                              // feed 本身的網址，由 handler 依請求路徑帶入
+}
@@ -0,0 +1,152 @@
+package post
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/feed"
+	"blog-backend/common/middleware"
+	"blog-backend/common/seo"
+	"blog-backend/common/utils"
+
+	"github.com/uptrace/bun"
+)
+
+const defaultFeedLimit = 20
+
+// 全站最新文章的 feed
+func (s *postServiceImpl) GetFeed(req GetFeedDto) (feed.Feed, error) {
+	ctx := context.Background()
+
+	title := feedSiteTitle()
+	f := feed.Feed{
+		Title:       title,
+		Description: feedDescription(title),
+		Link:        seo.SiteBaseURL() + "/",
+		SelfURL:     req.SelfURL,
+		Author:      feedAuthor(title),
+		Language:    feedLanguage(),
+	}
+	if err := s.fillFeedItems(ctx, &f, req, nil); err != nil {
+		return feed.Feed{}, err
+	}
+	return f, nil
+}
+
+// 分類的 feed，有子分類時包含所有子分類的文章（與分類文章列表相同）
+func (s *postServiceImpl) GetCategoryFeed(slug string, req GetFeedDto) (feed.Feed, error) {
+	ctx := context.Background()
+
+	category, categoryIDs, err := s.categoryScope(ctx, slug)
+	if err != nil {
+		return feed.Feed{}, err
+	}
+
+	siteTitle := feedSiteTitle()
+	title := fmt.Sprintf("%s - %s", siteTitle, category.Name)
+	f := feed.Feed{
+		Title:       title,
+		Description: fmt.Sprintf("%s「%s」分類的最新文章", siteTitle, category.Name),
+		Link:        seo.CategoryURL(category.Slug),
+		SelfURL:     req.SelfURL,
+		Author:      feedAuthor(siteTitle),
+		Language:    feedLanguage(),
+	}
+	if err := s.fillFeedItems(ctx, &f, req, categoryIDs); err != nil {
+		return feed.Feed{}, err
+	}
+	return f, nil
+}
+
+// 載入最新的可見文章，categoryIDs 為 nil 時不限分類；Updated 為這些文章中最新的 UpdatedAt
+func (s *postServiceImpl) fillFeedItems(ctx context.Context, f *feed.Feed, req GetFeedDto, categoryIDs []uint) error {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultFeedLimit
+	}
+	full := req.Content == "full"
+
+	columns := []string{"post.id", "post.slug", "post.title", "post.summary", "post.cover_image_url", "post.created_at", "post.updated_at"}
+	if full {
+		columns = append(columns, "post.content")
+	}
+
+	var posts []entity.Post
+	query := s.db.NewSelect().
+		Model(&posts).
+		Column(columns...).
+		Apply(visiblePosts)
+	if categoryIDs != nil {
+		query = query.Where("post.category_id IN (?)", bun.In(categoryIDs))
+	}
+	err := query.
+		OrderExpr("post.created_at DESC, post.id DESC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return middleware.ErrDB
+	}
+
+	f.Items = []feed.Item{}
+	for _, post := range posts {
+		link := seo.PostURL(post.Slug)
+		item := feed.Item{
+			ID:        link,
+			Title:     post.Title,
+			Link:      link,
+			Summary:   post.Summary,
+			Image:     post.CoverImageUrl,
+			Published: post.CreatedAt,
+			Updated:   post.UpdatedAt,
+		}
+		if full {
+			html, err := utils.RenderContent(post.Content, utils.ContentFormatHTML)
+			if err != nil {
+				// 單篇內容壞掉不影響整個 feed，這篇只輸出摘要
+				fmt.Printf("⚠️ feed 全文轉換失敗：文章 ID=%d，錯誤：%v\n", post.ID, err)
+			} else {
+				item.ContentHTML = html
+			}
+		}
+		if post.UpdatedAt.After(f.Updated) {
+			f.Updated = post.UpdatedAt
+		}
+		f.Items = append(f.Items, item)
+	}
+	return nil
+}
+
+// feed 標題使用 SITE_NAME
+func feedSiteTitle() string {
+	if name := strings.TrimSpace(os.Getenv("SITE_NAME")); name != "" {
+		return name
+	}
+	return "部落格"
+}
+
+// feed 說明（SITE_DESCRIPTION），未設定時與標題相同
+func feedDescription(title string) string {
+	if description := strings.TrimSpace(os.Getenv("SITE_DESCRIPTION")); description != "" {
+		return description
+	}
+	return title
+}
+
+// 作者（SITE_AUTHOR），未設定時用網站名稱（Atom 的 author 為必填）
+func feedAuthor(siteTitle string) string {
+	if author := strings.TrimSpace(os.Getenv("SITE_AUTHOR")); author != "" {
+		return author
+	}
+	return siteTitle
+}
+
+// 內容語言（SITE_LANGUAGE），預設 zh-TW
+func feedLanguage() string {
+	if language := strings.TrimSpace(os.Getenv("SITE_LANGUAGE")); language != "" {
+		return language
+	}
+	return "zh-TW"
+}
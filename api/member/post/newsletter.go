@@ -0,0 +1,147 @@
+package post
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/middleware"
+	"blog-backend/common/newsletter"
+)
+
+// 同一個 email 在這段時間內不會重複寄確認信
+const confirmationResendInterval = 10 * time.Minute
+
+// 訂閱電子報：寄出確認信，點擊確認連結後才會收到新文章通知
+// 不論 email 是否已訂閱都回傳相同結果，避免被用來查詢誰訂閱了
+func (s *postServiceImpl) Subscribe(req SubscribeDto) (SubscriptionResultDto, error) {
+	ctx := context.Background()
+	result := SubscriptionResultDto{Status: entity.SubscriberStatusPending}
+
+	if strings.TrimSpace(req.Website) != "" {
+		return result, nil
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	var subscriber entity.Subscriber
+	err := s.db.NewSelect().
+		Model(&subscriber).
+		Where("email = ?", email).
+		Limit(1).
+		Scan(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return SubscriptionResultDto{}, middleware.ErrDB
+	}
+
+	now := time.Now()
+	switch {
+	case subscriber.ID == 0:
+		subscriber = entity.Subscriber{
+			Email:     email,
+			Status:    entity.SubscriberStatusPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if _, err := s.db.NewInsert().Model(&subscriber).Returning("id").Exec(ctx); err != nil {
+			return SubscriptionResultDto{}, middleware.WrapDBErr("建立訂閱失敗", err)
+		}
+	case subscriber.Status == entity.SubscriberStatusConfirmed:
+		return result, nil
+	case subscriber.ConfirmationSentAt != nil && now.Sub(*subscriber.ConfirmationSentAt) < confirmationResendInterval:
+		return result, nil
+	}
+
+	if err := newsletter.SendConfirmation(ctx, s.mailer, subscriber); err != nil {
+		fmt.Printf("⚠️ 確認信寄送失敗：%s，錯誤：%v\n", email, err)
+		return SubscriptionResultDto{}, middleware.Newf(middleware.ErrExternalService.Code, "確認信寄送失敗，請稍後再試")
+	}
+
+	// 取消訂閱後重新訂閱，一樣要再確認一次
+	_, err = s.db.NewUpdate().
+		Model((*entity.Subscriber)(nil)).
+		Set("status = ?", entity.SubscriberStatusPending).
+		Set("confirmation_sent_at = ?", now).
+		Set("updated_at = ?", now).
+		Where("id = ?", subscriber.ID).
+		Where("status != ?", entity.SubscriberStatusConfirmed).
+		Exec(ctx)
+	if err != nil {
+		return SubscriptionResultDto{}, middleware.ErrDB
+	}
+
+	return result, nil
+}
+
+// 點擊確認信的連結後完成訂閱
+func (s *postServiceImpl) ConfirmSubscription(token string) (SubscriptionResultDto, error) {
+	ctx := context.Background()
+
+	subscriber, err := s.verifyNewsletterToken(ctx, newsletter.PurposeConfirm, token)
+	if err != nil {
+		return SubscriptionResultDto{}, err
+	}
+	if subscriber.Status == entity.SubscriberStatusConfirmed {
+		return SubscriptionResultDto{Email: subscriber.Email, Status: subscriber.Status}, nil
+	}
+
+	now := time.Now()
+	subscriber.Status = entity.SubscriberStatusConfirmed
+	subscriber.ConfirmedAt = &now
+	subscriber.UnsubscribedAt = nil
+	subscriber.UpdatedAt = now
+	_, err = s.db.NewUpdate().
+		Model(&subscriber).
+		Column("status", "confirmed_at", "unsubscribed_at", "updated_at").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return SubscriptionResultDto{}, middleware.ErrDB
+	}
+
+	return SubscriptionResultDto{Email: subscriber.Email, Status: subscriber.Status}, nil
+}
+
+// 取消訂閱（通知信中的連結，或信箱的一鍵取消訂閱）
+func (s *postServiceImpl) Unsubscribe(token string) (SubscriptionResultDto, error) {
+	ctx := context.Background()
+
+	subscriber, err := s.verifyNewsletterToken(ctx, newsletter.PurposeUnsubscribe, token)
+	if err != nil {
+		return SubscriptionResultDto{}, err
+	}
+	if subscriber.Status == entity.SubscriberStatusUnsubscribed {
+		return SubscriptionResultDto{Email: subscriber.Email, Status: subscriber.Status}, nil
+	}
+
+	now := time.Now()
+	subscriber.Status = entity.SubscriberStatusUnsubscribed
+	subscriber.UnsubscribedAt = &now
+	subscriber.UpdatedAt = now
+	_, err = s.db.NewUpdate().
+		Model(&subscriber).
+		Column("status", "unsubscribed_at", "updated_at").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return SubscriptionResultDto{}, middleware.ErrDB
+	}
+
+	return SubscriptionResultDto{Email: subscriber.Email, Status: subscriber.Status}, nil
+}
+
+func (s *postServiceImpl) verifyNewsletterToken(ctx context.Context, purpose, token string) (entity.Subscriber, error) {
+	subscriber, err := newsletter.VerifyToken(ctx, s.db, purpose, token)
+	switch {
+	case errors.Is(err, newsletter.ErrInvalidToken):
+		return entity.Subscriber{}, middleware.Newf(middleware.ErrBadRequest.Code, "連結無效，請確認網址是否完整")
+	case errors.Is(err, newsletter.ErrExpiredToken):
+		return entity.Subscriber{}, middleware.Newf(middleware.ErrBadRequest.Code, "連結已過期，請重新訂閱")
+	case err != nil:
+		return entity.Subscriber{}, middleware.ErrDB
+	}
+	return subscriber, nil
+}
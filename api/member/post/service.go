@@ -7,8 +7,14 @@ import (
 	"math/rand"
 
 	"blog-backend/common/entity"
+	"blog-backend/common/feed"
+	"blog-backend/common/mailer"
 	"blog-backend/common/middleware"
 	"blog-backend/common/model"
+	"blog-backend/common/preview"
+	"blog-backend/common/search"
+	"blog-backend/common/seo"
+	"blog-backend/common/spam"
 
 	"blog-backend/common/utils"
 
@@ -17,22 +23,72 @@ import (
 
 type PostService interface {
 	GetPostList(req GetPostListDto) (model.PaginatedResponse[PostListDto], error)
-	GetPostBySlug(slug string) (PostDto, error)
+	GetPostBySlug(slug string, req GetPostDto) (PostDto, error)
 	GetPostsByCategory(slug string, req GetPostListDto) (model.PaginatedResponse[PostListDto], error)
-	GetAboutMe() (AboutMeDto, error)
+	GetAboutMe(format string) (AboutMeDto, error)
 	GetRandomPostsByCategory(dto GetRandomPostsByCategoryDto) ([]PostListDto, error)
+	GetRelatedPosts(slug string, req GetRelatedPostsDto) ([]PostListDto, error)
+	GetPostsByTag(slug string, req GetPostListDto) (model.PaginatedResponse[PostListDto], error)
+	SearchPosts(req SearchPostDto) (model.PaginatedResponse[SearchResultDto], error)
+	RecordPostView(slug string, req RecordPostViewDto) (RecordPostViewResultDto, error)
+	GetPopularPosts(req GetPopularPostsDto) ([]PostListDto, error)
+	GetTrendingPosts(req GetPopularPostsDto) ([]PostListDto, error)
+	GetComments(slug string) ([]CommentDto, error)
+	SubmitComment(slug string, req SubmitCommentDto) (SubmitCommentResultDto, error)
+	GetFeed(req GetFeedDto) (feed.Feed, error)
+	GetCategoryFeed(slug string, req GetFeedDto) (feed.Feed, error)
+	GetSitemap() ([]byte, error)
+	GetSitemapPage(page string) ([]byte, error)
+	Subscribe(req SubscribeDto) (SubscriptionResultDto, error)
+	ConfirmSubscription(token string) (SubscriptionResultDto, error)
+	Unsubscribe(token string) (SubscriptionResultDto, error)
 }
 
 type postServiceImpl struct {
-	db *bun.DB
+	db         *bun.DB
+	spamScorer spam.Scorer
+	mailer     mailer.Mailer
+	sitemaps   *sitemapCache
 }
 
 func NewPostService(db *bun.DB) PostService {
+	return NewPostServiceWithSpamScorer(db, spam.NewHeuristic())
+}
+
+// NewPostServiceWithSpamScorer 使用指定的垃圾留言評分方式（例如外部服務）
+func NewPostServiceWithSpamScorer(db *bun.DB, scorer spam.Scorer) PostService {
 	return &postServiceImpl{
-		db: db,
+		db:         db,
+		spamScorer: scorer,
+		mailer:     mailer.FromEnv(),
+		sitemaps:   &sitemapCache{},
+	}
+}
+
+// 列表只需要的欄位，不載入 content
+func postListColumns(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.Column("post.slug", "post.title", "post.summary", "post.cover_image_url", "post.reading_minutes", "post.created_at")
+}
+
+func toPostListDto(post entity.Post) PostListDto {
+	return PostListDto{
+		Slug:           post.Slug,
+		Title:          post.Title,
+		Summary:        post.Summary,
+		CoverImageUrl:  post.CoverImageUrl,
+		ReadingMinutes: post.ReadingMinutes,
+		CreatedAt:      post.CreatedAt,
 	}
 }
 
+// 前台只能看到可見的文章：已發佈（或排程發佈時間已到）、尚未到下架時間、未刪除
+func visiblePosts(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.
+		Where("post.is_deleted = FALSE").
+		Where("(post.is_published = TRUE OR post.publish_at <= NOW())").
+		Where("(post.unpublish_at IS NULL OR post.unpublish_at > NOW())")
+}
+
 func (s *postServiceImpl) GetPostList(req GetPostListDto) (model.PaginatedResponse[PostListDto], error) {
 	ctx := context.Background()
 
@@ -46,8 +102,7 @@ func (s *postServiceImpl) GetPostList(req GetPostListDto) (model.PaginatedRespon
 	// 查詢總筆數
 	total, err := s.db.NewSelect().
 		Model((*entity.Post)(nil)).
-		Where("is_published = TRUE").
-		Where("is_deleted = FALSE").
+		Apply(visiblePosts).
 		Count(ctx)
 	if err != nil {
 		return model.PaginatedResponse[PostListDto]{}, middleware.ErrDB
@@ -57,8 +112,8 @@ func (s *postServiceImpl) GetPostList(req GetPostListDto) (model.PaginatedRespon
 	var posts []entity.Post
 	err = s.db.NewSelect().
 		Model(&posts).
-		Where("is_published = TRUE").
-		Where("is_deleted = FALSE").
+		Apply(postListColumns).
+		Apply(visiblePosts).
 		Order("created_at DESC").
 		Limit(req.Limit).
 		Offset((req.Page - 1) * req.Limit).
@@ -70,13 +125,7 @@ func (s *postServiceImpl) GetPostList(req GetPostListDto) (model.PaginatedRespon
 	// 組裝結果
 	var result []PostListDto
 	for _, post := range posts {
-		result = append(result, PostListDto{
-			Slug:          post.Slug,
-			Title:         post.Title,
-			Summary:       utils.ExtractSummaryFromEditorJS(post.Content, 200),
-			CoverImageUrl: post.CoverImageUrl,
-			CreatedAt:     post.CreatedAt,
-		})
+		result = append(result, toPostListDto(post))
 	}
 
 	// 回傳文章清單、總筆數
@@ -88,33 +137,154 @@ func (s *postServiceImpl) GetPostList(req GetPostListDto) (model.PaginatedRespon
 	}, nil
 }
 
-func (s *postServiceImpl) GetPostBySlug(slug string) (PostDto, error) {
+func (s *postServiceImpl) GetPostBySlug(slug string, req GetPostDto) (PostDto, error) {
+	ctx := context.Background()
+
+	if req.Preview != "" {
+		return s.getPostPreview(ctx, slug, req)
+	}
+
 	var post entity.Post
 	err := s.db.NewSelect().
 		Model(&post).
 		Where("post.slug = ?", slug).
-		Where("is_published = TRUE").
-		Where("is_deleted = FALSE").
+		Apply(visiblePosts).
 		Limit(1).
-		Scan(context.Background())
+		Scan(ctx)
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return PostDto{}, s.findMovedPost(ctx, slug)
+	} else if err != nil {
+		return PostDto{}, middleware.ErrDB
+	}
+
+	return s.toPostDto(ctx, post, req.Format)
+}
+
+// 用預覽 token 讀取文章：不檢查發佈狀態，但 token 必須有效、未撤銷，且屬於這篇文章
+func (s *postServiceImpl) getPostPreview(ctx context.Context, slug string, req GetPostDto) (PostDto, error) {
+	claims, err := preview.Verify(ctx, s.db, req.Preview)
+	switch {
+	case errors.Is(err, preview.ErrInvalidToken), errors.Is(err, preview.ErrExpiredToken), errors.Is(err, preview.ErrRevokedToken):
+		return PostDto{}, middleware.Newf(middleware.ErrUnauthorized.Code, "%v", err)
+	case err != nil:
+		return PostDto{}, middleware.ErrDB
+	}
+
+	var post entity.Post
+	err = s.db.NewSelect().
+		Model(&post).
+		Where("post.id = ?", claims.PostID).
+		Where("post.is_deleted = FALSE").
+		Limit(1).
+		Scan(ctx)
 	if errors.Is(err, sql.ErrNoRows) {
 		return PostDto{}, middleware.ErrNotFound
 	} else if err != nil {
 		return PostDto{}, middleware.ErrDB
 	}
 
+	// 產生連結後文章改了 slug：回傳目前的 slug 讓前端帶著 token 轉址
+	if post.Slug != slug {
+		return PostDto{}, middleware.ErrMoved.WithData(model.MovedData{
+			Type:          utils.SlugEntityPost,
+			CanonicalSlug: post.Slug,
+		})
+	}
+
+	dto, err := s.toPostDto(ctx, post, req.Format)
+	if err != nil {
+		return PostDto{}, err
+	}
+	dto.Preview = true
+	// 預覽頁面不能被搜尋引擎收錄
+	dto.Seo.NoIndex = true
+	return dto, nil
+}
+
+func (s *postServiceImpl) toPostDto(ctx context.Context, post entity.Post, format string) (PostDto, error) {
+	// 文章的標籤
+	var tags []entity.Tag
+	err := s.db.NewSelect().
+		Model(&tags).
+		Join("JOIN post_tags AS pt ON pt.tag_id = tag.id").
+		Where("pt.post_id = ?", post.ID).
+		Order("tag.name ASC").
+		Scan(ctx)
+	if err != nil {
+		return PostDto{}, middleware.ErrDB
+	}
+
+	tagDtos := make([]TagDto, 0, len(tags))
+	tagNames := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tagDtos = append(tagDtos, TagDto{Name: tag.Name, Slug: tag.Slug})
+		tagNames = append(tagNames, tag.Name)
+	}
+
+	content, format, err := renderContent(post.Content, format)
+	if err != nil {
+		return PostDto{}, err
+	}
+
+	// 麵包屑使用的分類路徑
+	categories, err := seo.CategoryChain(ctx, s.db, post.CategoryID)
+	if err != nil {
+		return PostDto{}, middleware.ErrDB
+	}
+	meta := seo.ResolveMeta(post)
+
 	dto := PostDto{
-		Title:         post.Title,
-		Summary:       utils.ExtractSummaryFromEditorJS(post.Content, 200),
-		Content:       post.Content,
-		CategoryID:    post.CategoryID,
-		CoverImageUrl: post.CoverImageUrl,
-		CreatedAt:     post.CreatedAt,
+		Title:          post.Title,
+		Summary:        post.Summary,
+		Content:        content,
+		Format:         format,
+		CategoryID:     post.CategoryID,
+		CoverImageUrl:  post.CoverImageUrl,
+		CreatedAt:      post.CreatedAt,
+		Tags:           tagDtos,
+		Toc:            post.Toc,
+		WordCount:      post.WordCount,
+		ReadingMinutes: post.ReadingMinutes,
+		Seo:            meta,
+		JsonLd:         seo.PostJSONLD(post, meta, categories, tagNames),
+	}
+	if dto.Toc == nil {
+		dto.Toc = []entity.TocItem{}
 	}
 	return dto, nil
 }
 
+// 找不到 slug 時查 slug 歷史：文章改過 slug 就回傳 ErrMoved（附上目前的 slug），否則回傳 ErrNotFound
+func (s *postServiceImpl) findMovedPost(ctx context.Context, slug string) error {
+	postID, found, err := utils.FindSlugRedirect(ctx, s.db, utils.SlugEntityPost, slug)
+	if err != nil {
+		return middleware.ErrDB
+	}
+	if !found {
+		return middleware.ErrNotFound
+	}
+
+	var post entity.Post
+	err = s.db.NewSelect().
+		Model(&post).
+		Column("slug").
+		Where("post.id = ?", postID).
+		Apply(visiblePosts).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return middleware.ErrNotFound
+	} else if err != nil {
+		return middleware.ErrDB
+	}
+
+	return middleware.ErrMoved.WithData(model.MovedData{
+		Type:          utils.SlugEntityPost,
+		CanonicalSlug: post.Slug,
+	})
+}
+
 func (s *postServiceImpl) GetPostsByCategory(slug string, req GetPostListDto) (model.PaginatedResponse[PostListDto], error) {
 	ctx := context.Background()
 
@@ -125,6 +295,52 @@ func (s *postServiceImpl) GetPostsByCategory(slug string, req GetPostListDto) (m
 		req.Limit = 15
 	}
 
+	_, categoryIDs, err := s.categoryScope(ctx, slug)
+	if err != nil {
+		return model.PaginatedResponse[PostListDto]{}, err
+	}
+
+	// ✅ 查詢總筆數
+	total, err := s.db.NewSelect().
+		Model((*entity.Post)(nil)).
+		Where("category_id IN (?)", bun.In(categoryIDs)).
+		Apply(visiblePosts).
+		Count(ctx)
+	if err != nil {
+		return model.PaginatedResponse[PostListDto]{}, middleware.ErrDB
+	}
+
+	// ✅ 分頁查詢資料
+	var posts []entity.Post
+	err = s.db.NewSelect().
+		Model(&posts).
+		Apply(postListColumns).
+		Where("category_id IN (?)", bun.In(categoryIDs)).
+		Apply(visiblePosts).
+		Order("created_at DESC").
+		Limit(req.Limit).
+		Offset((req.Page - 1) * req.Limit).
+		Scan(ctx)
+	if err != nil {
+		return model.PaginatedResponse[PostListDto]{}, middleware.ErrDB
+	}
+
+	// 組裝 DTO
+	var result []PostListDto
+	for _, post := range posts {
+		result = append(result, toPostListDto(post))
+	}
+
+	return model.PaginatedResponse[PostListDto]{
+		Page:       req.Page,
+		Limit:      req.Limit,
+		TotalCount: total,
+		Data:       result,
+	}, nil
+}
+
+// 分類頁包含的分類 ID：有子分類時為所有子分類，沒有子分類時為分類本身（分類文章列表與分類 feed 共用）
+func (s *postServiceImpl) categoryScope(ctx context.Context, slug string) (entity.Category, []uint, error) {
 	// 找出該分類是主分類還是子分類
 	var category entity.Category
 	err := s.db.NewSelect().
@@ -132,8 +348,10 @@ func (s *postServiceImpl) GetPostsByCategory(slug string, req GetPostListDto) (m
 		Where("slug = ?", slug).
 		Limit(1).
 		Scan(ctx)
-	if err != nil {
-		return model.PaginatedResponse[PostListDto]{}, middleware.ErrDB
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Category{}, nil, middleware.Newf(middleware.ErrNotFound.Code, "找不到分類：%s", slug)
+	} else if err != nil {
+		return entity.Category{}, nil, middleware.ErrDB
 	}
 
 	var categoryIDs []uint
@@ -146,7 +364,7 @@ func (s *postServiceImpl) GetPostsByCategory(slug string, req GetPostListDto) (m
 			Where("parent = ?", category.ID).
 			Scan(ctx)
 		if err != nil {
-			return model.PaginatedResponse[PostListDto]{}, middleware.ErrDB
+			return entity.Category{}, nil, middleware.ErrDB
 		}
 
 		for _, sub := range subCategories {
@@ -156,13 +374,41 @@ func (s *postServiceImpl) GetPostsByCategory(slug string, req GetPostListDto) (m
 		// 沒有子分類：表示主分類可擁有自己的文章
 		categoryIDs = append(categoryIDs, category.ID)
 	}
+	return category, categoryIDs, nil
+}
+
+func (s *postServiceImpl) GetPostsByTag(slug string, req GetPostListDto) (model.PaginatedResponse[PostListDto], error) {
+	ctx := context.Background()
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 15
+	}
+
+	var tag entity.Tag
+	err := s.db.NewSelect().
+		Model(&tag).
+		Where("slug = ?", slug).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.PaginatedResponse[PostListDto]{}, middleware.ErrNotFound
+	} else if err != nil {
+		return model.PaginatedResponse[PostListDto]{}, middleware.ErrDB
+	}
+
+	taggedPosts := s.db.NewSelect().
+		Model((*entity.PostTag)(nil)).
+		Column("post_id").
+		Where("tag_id = ?", tag.ID)
 
 	// ✅ 查詢總筆數
 	total, err := s.db.NewSelect().
 		Model((*entity.Post)(nil)).
-		Where("category_id IN (?)", bun.In(categoryIDs)).
-		Where("is_published = TRUE").
-		Where("is_deleted = FALSE").
+		Where("post.id IN (?)", taggedPosts).
+		Apply(visiblePosts).
 		Count(ctx)
 	if err != nil {
 		return model.PaginatedResponse[PostListDto]{}, middleware.ErrDB
@@ -172,9 +418,9 @@ func (s *postServiceImpl) GetPostsByCategory(slug string, req GetPostListDto) (m
 	var posts []entity.Post
 	err = s.db.NewSelect().
 		Model(&posts).
-		Where("category_id IN (?)", bun.In(categoryIDs)).
-		Where("is_published = TRUE").
-		Where("is_deleted = FALSE").
+		Apply(postListColumns).
+		Where("post.id IN (?)", taggedPosts).
+		Apply(visiblePosts).
 		Order("created_at DESC").
 		Limit(req.Limit).
 		Offset((req.Page - 1) * req.Limit).
@@ -186,13 +432,7 @@ func (s *postServiceImpl) GetPostsByCategory(slug string, req GetPostListDto) (m
 	// 組裝 DTO
 	var result []PostListDto
 	for _, post := range posts {
-		result = append(result, PostListDto{
-			Slug:          post.Slug,
-			Title:         post.Title,
-			Summary:       utils.ExtractSummaryFromEditorJS(post.Content, 200),
-			CoverImageUrl: post.CoverImageUrl,
-			CreatedAt:     post.CreatedAt,
-		})
+		result = append(result, toPostListDto(post))
 	}
 
 	return model.PaginatedResponse[PostListDto]{
@@ -203,7 +443,81 @@ func (s *postServiceImpl) GetPostsByCategory(slug string, req GetPostListDto) (m
 	}, nil
 }
 
-func (s *postServiceImpl) GetAboutMe() (AboutMeDto, error) {
+func (s *postServiceImpl) SearchPosts(req SearchPostDto) (model.PaginatedResponse[SearchResultDto], error) {
+	ctx := context.Background()
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 15
+	}
+
+	// 查詢字串只有標點或空白時，直接回傳空結果
+	queryText := search.QueryText(req.Q)
+	if queryText == "" {
+		return model.PaginatedResponse[SearchResultDto]{Page: req.Page, Limit: req.Limit}, nil
+	}
+
+	matched := func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.
+			Join("JOIN post_search_index AS psi ON psi.post_id = post.id").
+			Where("psi.document @@ plainto_tsquery('simple', ?)", queryText).
+			Apply(visiblePosts)
+	}
+
+	// ✅ 查詢總筆數
+	total, err := s.db.NewSelect().
+		Model((*entity.Post)(nil)).
+		Apply(matched).
+		Count(ctx)
+	if err != nil {
+		return model.PaginatedResponse[SearchResultDto]{}, middleware.ErrDB
+	}
+
+	// ✅ 依相關度排序，同分時新的文章在前
+	var rows []struct {
+		entity.Post
+		SearchBody string  `bun:"search_body"`
+		Score      float64 `bun:"score"`
+	}
+	err = s.db.NewSelect().
+		Model((*entity.Post)(nil)).
+		ColumnExpr("post.slug, post.title, post.summary, post.cover_image_url, post.created_at").
+		ColumnExpr("psi.body AS search_body").
+		ColumnExpr("ts_rank(psi.document, plainto_tsquery('simple', ?)) AS score", queryText).
+		Apply(matched).
+		OrderExpr("score DESC").
+		Order("post.created_at DESC").
+		Limit(req.Limit).
+		Offset((req.Page-1)*req.Limit).
+		Scan(ctx, &rows)
+	if err != nil {
+		return model.PaginatedResponse[SearchResultDto]{}, middleware.ErrDB
+	}
+
+	var result []SearchResultDto
+	for _, row := range rows {
+		result = append(result, SearchResultDto{
+			Slug:          row.Slug,
+			Title:         row.Title,
+			Summary:       row.Summary,
+			Snippet:       search.Snippet(row.SearchBody, req.Q, 120),
+			CoverImageUrl: row.CoverImageUrl,
+			CreatedAt:     row.CreatedAt,
+			Score:         row.Score,
+		})
+	}
+
+	return model.PaginatedResponse[SearchResultDto]{
+		Page:       req.Page,
+		Limit:      req.Limit,
+		TotalCount: total,
+		Data:       result,
+	}, nil
+}
+
+func (s *postServiceImpl) GetAboutMe(format string) (AboutMeDto, error) {
 	var about entity.AboutMe
 	err := s.db.NewSelect().
 		Model(&about).
@@ -215,9 +529,15 @@ func (s *postServiceImpl) GetAboutMe() (AboutMeDto, error) {
 		return AboutMeDto{}, middleware.ErrDB
 	}
 
+	content, format, err := renderContent(about.HtmlContent, format)
+	if err != nil {
+		return AboutMeDto{}, err
+	}
+
 	return AboutMeDto{
 		ID:        about.ID,
-		Content:   about.HtmlContent,
+		Content:   content,
+		Format:    format,
 		UpdatedAt: about.UpdatedAt,
 	}, nil
 }
@@ -260,8 +580,8 @@ func (s *postServiceImpl) GetRandomPostsByCategory(dto GetRandomPostsByCategoryD
 	var posts []entity.Post
 	query := s.db.NewSelect().
 		Model(&posts).
-		Where("is_published = TRUE").
-		Where("is_deleted = FALSE").
+		Apply(postListColumns).
+		Apply(visiblePosts).
 		Where("category_id IN (?)", bun.In(categoryIDs))
 
 	if dto.Slug != "" {
@@ -291,14 +611,80 @@ func (s *postServiceImpl) GetRandomPostsByCategory(dto GetRandomPostsByCategoryD
 
 	var result []PostListDto
 	for _, post := range selected {
-		result = append(result, PostListDto{
-			Slug:          post.Slug,
-			Title:         post.Title,
-			Summary:       utils.ExtractSummaryFromEditorJS(post.Content, 200),
-			CoverImageUrl: post.CoverImageUrl,
-			CreatedAt:     post.CreatedAt,
-		})
+		result = append(result, toPostListDto(post))
+	}
+
+	return result, nil
+}
+
+// 相關文章在後台儲存文章時就算好（search.RefreshRelatedPosts），這裡只讀取 post_related
+func (s *postServiceImpl) GetRelatedPosts(slug string, req GetRelatedPostsDto) ([]PostListDto, error) {
+	ctx := context.Background()
+
+	if req.Limit <= 0 {
+		req.Limit = 6
+	}
+
+	var post entity.Post
+	err := s.db.NewSelect().
+		Model(&post).
+		Column("post.id", "post.category_id").
+		Where("post.slug = ?", slug).
+		Apply(visiblePosts).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, s.findMovedPost(ctx, slug)
+	} else if err != nil {
+		return nil, middleware.ErrDB
+	}
+
+	// 分數相同時依文章 ID 排序，每次回傳的順序都一樣
+	var posts []entity.Post
+	err = s.db.NewSelect().
+		Model(&posts).
+		Apply(postListColumns).
+		Join("JOIN post_related AS related ON related.related_post_id = post.id").
+		Where("related.post_id = ?", post.ID).
+		Apply(visiblePosts).
+		OrderExpr("related.score DESC, post.id ASC").
+		Limit(req.Limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, middleware.ErrDB
 	}
 
+	// 還沒計算過相關文章（例如剛部署、尚未執行批次）時，改回傳同分類的最新文章
+	if len(posts) == 0 {
+		err = s.db.NewSelect().
+			Model(&posts).
+			Apply(postListColumns).
+			Apply(visiblePosts).
+			Where("post.category_id = ?", post.CategoryID).
+			Where("post.id != ?", post.ID).
+			OrderExpr("post.created_at DESC, post.id DESC").
+			Limit(req.Limit).
+			Scan(ctx)
+		if err != nil {
+			return nil, middleware.ErrDB
+		}
+	}
+
+	result := []PostListDto{}
+	for _, p := range posts {
+		result = append(result, toPostListDto(p))
+	}
 	return result, nil
 }
+
+// 依照要求的格式轉換 Editor.js 內容，未指定時回傳原始 JSON
+func renderContent(content, format string) (string, string, error) {
+	if format == "" {
+		format = utils.ContentFormatEditorJS
+	}
+	rendered, err := utils.RenderContent(content, format)
+	if err != nil {
+		return "", "", middleware.Newf(middleware.ErrDataError.Code, "文章內容無法解析：%v", err)
+	}
+	return rendered, format, nil
+}
@@ -0,0 +1,236 @@
+package post
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/middleware"
+	"blog-backend/common/seo"
+	"blog-backend/common/sitemap"
+	"blog-backend/common/utils"
+)
+
+// 內容版本沒變時，sitemap 最多快取這麼久（SITEMAP_CACHE_MINUTES 可調整）；
+// 排程發佈的文章時間到就會出現在前台，不一定有後台異動，所以仍需要定期重新產生
+const defaultSitemapCacheMinutes = 60
+
+// 產生好的 sitemap，依內容版本（content_versions）判斷是否過期
+type sitemapCache struct {
+	mu          sync.Mutex
+	version     int64
+	generatedAt time.Time
+	files       [][]byte // [0] 為 sitemap.xml；網址超過上限時 [0] 為 sitemap index，[n] 為第 n 個 sitemap 檔
+}
+
+// sitemap.xml：網址不超過 50,000 個時直接是 sitemap，否則是 sitemap index
+func (s *postServiceImpl) GetSitemap() ([]byte, error) {
+	files, err := s.sitemapFiles(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return files[0], nil
+}
+
+// sitemap index 中的第 page 個 sitemap 檔（從 1 開始）
+func (s *postServiceImpl) GetSitemapPage(page string) ([]byte, error) {
+	n, err := strconv.Atoi(page)
+	if err != nil {
+		return nil, middleware.ErrBadRequest
+	}
+
+	files, err := s.sitemapFiles(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n >= len(files) {
+		return nil, middleware.ErrNotFound
+	}
+	return files[n], nil
+}
+
+// 取得快取的 sitemap，內容版本改變或快取過久時重新產生
+func (s *postServiceImpl) sitemapFiles(ctx context.Context) ([][]byte, error) {
+	version, err := utils.CurrentContentVersion(ctx, s.db)
+	if err != nil {
+		return nil, middleware.ErrDB
+	}
+
+	cache := s.sitemaps
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.files != nil && cache.version == version && time.Since(cache.generatedAt) < sitemapCacheTTL() {
+		return cache.files, nil
+	}
+
+	urls, err := s.sitemapURLs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files, err := renderSitemapFiles(urls)
+	if err != nil {
+		return nil, middleware.Newf(middleware.ErrDataError.Code, "產生 sitemap 失敗：%v", err)
+	}
+
+	cache.version = version
+	cache.generatedAt = time.Now()
+	cache.files = files
+	return files, nil
+}
+
+// 首頁、可見的文章（含封面圖片）、分類頁與關於我
+func (s *postServiceImpl) sitemapURLs(ctx context.Context) ([]sitemap.URL, error) {
+	var posts []entity.Post
+	err := s.db.NewSelect().
+		Model(&posts).
+		Column("post.slug", "post.category_id", "post.cover_image_url", "post.canonical_url", "post.no_index", "post.updated_at").
+		Apply(visiblePosts).
+		OrderExpr("post.created_at DESC, post.id DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, middleware.ErrDB
+	}
+
+	var categories []entity.Category
+	err = s.db.NewSelect().
+		Model(&categories).
+		Column("id", "slug", "parent", "has_children", "sort_order", "updated_at").
+		OrderExpr("sort_order ASC, id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, middleware.ErrDB
+	}
+
+	var about entity.AboutMe
+	err = s.db.NewSelect().
+		Model(&about).
+		Column("id", "updated_at").
+		Limit(1).
+		Scan(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, middleware.ErrDB
+	}
+
+	postURLs := []sitemap.URL{}
+	latestByCategory := make(map[uint]time.Time)
+	var latest time.Time
+	for _, post := range posts {
+		if post.UpdatedAt.After(latestByCategory[post.CategoryID]) {
+			latestByCategory[post.CategoryID] = post.UpdatedAt
+		}
+		if post.UpdatedAt.After(latest) {
+			latest = post.UpdatedAt
+		}
+
+		// 不給索引、或 canonical 指向其他網址的文章不放進 sitemap
+		meta := seo.ResolveMeta(post)
+		if meta.NoIndex || meta.CanonicalUrl != seo.PostURL(post.Slug) {
+			continue
+		}
+		u := sitemap.URL{Loc: meta.CanonicalUrl, LastMod: post.UpdatedAt}
+		if post.CoverImageUrl != "" {
+			u.Images = []string{post.CoverImageUrl}
+		}
+		postURLs = append(postURLs, u)
+	}
+
+	urls := []sitemap.URL{{Loc: seo.SiteBaseURL() + "/", LastMod: latest}}
+	urls = append(urls, categorySitemapURLs(categories, latestByCategory)...)
+	if about.ID != 0 {
+		urls = append(urls, sitemap.URL{Loc: seo.AboutURL(), LastMod: about.UpdatedAt})
+	}
+	return append(urls, postURLs...), nil
+}
+
+// 依分類樹的順序列出分類頁；lastmod 取分類本身與分類頁上文章（有子分類時為子分類的文章）中最新的時間
+func categorySitemapURLs(categories []entity.Category, latestByCategory map[uint]time.Time) []sitemap.URL {
+	// categories 已依 sort_order 排序，同層的順序與分類樹相同
+	children := make(map[uint][]entity.Category)
+	roots := []entity.Category{}
+	for _, category := range categories {
+		if category.Parent == nil {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.Parent] = append(children[*category.Parent], category)
+	}
+
+	urls := []sitemap.URL{}
+	visited := make(map[uint]bool)
+	var walk func(category entity.Category)
+	walk = func(category entity.Category) {
+		if visited[category.ID] {
+			return
+		}
+		visited[category.ID] = true
+
+		lastMod := category.UpdatedAt
+		scope := []entity.Category{category}
+		if category.HasChildren {
+			scope = children[category.ID]
+		}
+		for _, c := range scope {
+			if latestByCategory[c.ID].After(lastMod) {
+				lastMod = latestByCategory[c.ID]
+			}
+		}
+		urls = append(urls, sitemap.URL{Loc: seo.CategoryURL(category.Slug), LastMod: lastMod})
+
+		for _, child := range children[category.ID] {
+			walk(child)
+		}
+	}
+	for _, root := range roots {
+		walk(root)
+	}
+	return urls
+}
+
+// 網址不超過上限時只有一個 sitemap；超過時切成多個檔案，sitemap.xml 改為 index
+// index 中的網址為 /sitemap-<n>.xml，由 Worker 轉給 /api/post/sitemap/<n>
+func renderSitemapFiles(urls []sitemap.URL) ([][]byte, error) {
+	if len(urls) <= sitemap.MaxURLs {
+		file, err := sitemap.RenderURLSet(urls)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{file}, nil
+	}
+
+	chunks := sitemap.Split(urls, sitemap.MaxURLs)
+	files := make([][]byte, 1, len(chunks)+1)
+	entries := []sitemap.IndexEntry{}
+	for i, chunk := range chunks {
+		file, err := sitemap.RenderURLSet(chunk)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+		entries = append(entries, sitemap.IndexEntry{
+			Loc:     fmt.Sprintf("%s/sitemap-%d.xml", seo.SiteBaseURL(), i+1),
+			LastMod: sitemap.LatestMod(chunk),
+		})
+	}
+
+	index, err := sitemap.RenderIndex(entries)
+	if err != nil {
+		return nil, err
+	}
+	files[0] = index
+	return files, nil
+}
+
+func sitemapCacheTTL() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("SITEMAP_CACHE_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = defaultSitemapCacheMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
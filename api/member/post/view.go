@@ -0,0 +1,144 @@
+package post
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/middleware"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	// 同一位訪客在這段時間內重複瀏覽同一篇文章只算一次（POST_VIEW_DEDUP_MINUTES 可調整）
+	defaultViewDedupMinutes = 30
+
+	// 熱門趨勢：瀏覽數每 3 天減半，只看最近 30 天
+	trendingHalfLifeDays = 3
+	trendingWindowDays   = 30
+)
+
+// 不計入瀏覽數的 User-Agent（搜尋引擎、社群預覽、監控與程式化的請求）
+var botUserAgentRegex = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|archiver|facebookexternalhit|embedly|preview|headless|lighthouse|pingdom|uptime|monitor|curl|wget|python-|go-http-client|java/|okhttp|axios|node-fetch|httpclient|scrapy|phantomjs|selenium|puppeteer|playwright`)
+
+// 記錄一次文章瀏覽：過濾機器人與重複瀏覽後寫入 post_views，每天由批次彙總
+func (s *postServiceImpl) RecordPostView(slug string, req RecordPostViewDto) (RecordPostViewResultDto, error) {
+	ctx := context.Background()
+
+	userAgent := strings.TrimSpace(req.UserAgent)
+	if userAgent == "" || botUserAgentRegex.MatchString(userAgent) {
+		return RecordPostViewResultDto{Counted: false, Reason: "bot"}, nil
+	}
+
+	postID, err := s.findVisiblePostID(ctx, slug)
+	if err != nil {
+		return RecordPostViewResultDto{}, err
+	}
+
+	now := time.Now()
+	fingerprint := viewFingerprint(strings.TrimSpace(req.ClientIP), userAgent)
+
+	duplicated, err := s.db.NewSelect().
+		Model((*entity.PostView)(nil)).
+		Where("post_id = ?", postID).
+		Where("fingerprint = ?", fingerprint).
+		Where("viewed_at > ?", now.Add(-viewDedupWindow())).
+		Exists(ctx)
+	if err != nil {
+		return RecordPostViewResultDto{}, middleware.ErrDB
+	}
+	if duplicated {
+		return RecordPostViewResultDto{Counted: false, Reason: "duplicate"}, nil
+	}
+
+	view := entity.PostView{
+		PostID:      postID,
+		Fingerprint: fingerprint,
+		ViewedAt:    now,
+	}
+	if _, err := s.db.NewInsert().Model(&view).Exec(ctx); err != nil {
+		return RecordPostViewResultDto{}, middleware.ErrDB
+	}
+
+	return RecordPostViewResultDto{Counted: true}, nil
+}
+
+// 熱門文章：所有時間的瀏覽數（來自每日彙總）
+func (s *postServiceImpl) GetPopularPosts(req GetPopularPostsDto) ([]PostListDto, error) {
+	views := s.db.NewSelect().
+		Model((*entity.PostViewDaily)(nil)).
+		Column("post_id").
+		ColumnExpr("SUM(views) AS score").
+		Group("post_id")
+
+	return s.postsByViewScore(views, req)
+}
+
+// 熱門趨勢：每天的瀏覽數依天數衰減後加總，最近的瀏覽權重較高
+func (s *postServiceImpl) GetTrendingPosts(req GetPopularPostsDto) ([]PostListDto, error) {
+	views := s.db.NewSelect().
+		Model((*entity.PostViewDaily)(nil)).
+		Column("post_id").
+		ColumnExpr("SUM(views * POWER(0.5, (CURRENT_DATE - day) / ?::float)) AS score", trendingHalfLifeDays).
+		Where("day > CURRENT_DATE - ?::int", trendingWindowDays).
+		Group("post_id")
+
+	return s.postsByViewScore(views, req)
+}
+
+// 依瀏覽分數排序可見的文章，分數相同時較新的文章在前
+func (s *postServiceImpl) postsByViewScore(views *bun.SelectQuery, req GetPopularPostsDto) ([]PostListDto, error) {
+	ctx := context.Background()
+
+	if req.Limit <= 0 {
+		req.Limit = 6
+	}
+
+	var posts []entity.Post
+	err := s.db.NewSelect().
+		Model(&posts).
+		Apply(postListColumns).
+		Join("JOIN (?) AS views ON views.post_id = post.id", views).
+		Apply(visiblePosts).
+		OrderExpr("views.score DESC, post.created_at DESC, post.id DESC").
+		Limit(req.Limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, middleware.ErrDB
+	}
+
+	result := []PostListDto{}
+	for _, p := range posts {
+		result = append(result, toPostListDto(p))
+	}
+	return result, nil
+}
+
+// 訪客指紋：IP + User-Agent 的雜湊
+func viewFingerprint(clientIP, userAgent string) string {
+	return visitorHash(clientIP + "|" + userAgent)
+}
+
+// 訪客資料的雜湊，有設定 VIEW_FINGERPRINT_SECRET 時改用 HMAC，無法從資料庫反推 IP（瀏覽紀錄與留言共用）
+func visitorHash(message string) string {
+	if signature, ok := middleware.SignHMAC(message, "VIEW_FINGERPRINT_SECRET"); ok {
+		return signature
+	}
+	sum := sha256.Sum256([]byte(message))
+	return hex.EncodeToString(sum[:])
+}
+
+func viewDedupWindow() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("POST_VIEW_DEDUP_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = defaultViewDedupMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
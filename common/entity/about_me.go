@@ -12,4 +12,5 @@ type AboutMe struct {
 	ID          uint      `bun:"id,pk,autoincrement"`
 	HtmlContent string    `bun:"html_content,type:text,notnull"`
 	UpdatedAt   time.Time `bun:"updated_at,notnull"`
+	Version     int       `bun:"version,notnull,default:1"` // 每次修改加 1，用來偵測同時編輯的衝突
 }
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// 留言狀態
+const (
+	CommentStatusPending  = "pending"  // 等待審核
+	CommentStatusApproved = "approved" // 已核准，前台可見
+	CommentStatusSpam     = "spam"     // 垃圾留言
+	CommentStatusDeleted  = "deleted"  // 已退回或刪除
+)
+
+// Comment 讀者留言，ParentID 有值時為回覆
+type Comment struct {
+	bun.BaseModel `bun:"table:comments"`
+
+	ID          uint      `bun:",pk,autoincrement"`
+	PostID      uint      `bun:",notnull"`                           // 文章 ID
+	ParentID    *uint     `bun:"parent_id"`                          // 回覆的留言 ID，最上層留言為 null
+	AuthorName  string    `bun:",notnull"`                           // 留言者名稱
+	AuthorEmail string    `bun:",notnull"`                           // 留言者 email（不會在前台顯示）
+	Content     string    `bun:",type:text,notnull"`                 // 純文字內容
+	Status      string    `bun:",notnull,default:'pending'"`         // pending、approved、spam 或 deleted
+	SpamScore   float64   `bun:",notnull,default:0"`                 // 垃圾留言分數（0 ~ 1）
+	IPHash      string    `bun:"ip_hash,notnull,default:''"`         // 留言者 IP 的雜湊（限制留言頻率用）
+	UserAgent   string    `bun:",notnull,default:''"`                // 留言者的 User-Agent
+	CreatedAt   time.Time `bun:",notnull,default:current_timestamp"` // 留言時間
+	UpdatedAt   time.Time `bun:",notnull,default:current_timestamp"` // 最後修改（審核）時間
+}
@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ContentVersion 全站內容的版本號（只有一筆，ID = 1），後台每次異動內容都會加 1，
+// 前台由內容產生的快取（例如 sitemap）版本號不同時就重新產生
+type ContentVersion struct {
+	bun.BaseModel `bun:"table:content_versions"`
+
+	ID        uint      `bun:",pk"`
+	Version   int64     `bun:",notnull,default:0"`
+	UpdatedAt time.Time `bun:",notnull,default:current_timestamp"`
+}
@@ -9,15 +9,39 @@ import (
 type Post struct {
 	bun.BaseModel `bun:"table:posts"`
 
-	ID            uint      `bun:",pk,autoincrement,notnull"`          // 主鍵，自動遞增，不為 null
-	Title         string    `bun:",notnull"`                           // 標題，不為 null
-	CategoryID    uint      `bun:",notnull"`                           // 分類 ID，不為 null
-	IsPublished   bool      `bun:",notnull"`                           // 是否發佈，不為 null
-	Slug          string    `bun:",unique,notnull"`                    // 對 SEO 友善的唯一識別 slug
-	CreatedAt     time.Time `bun:",notnull,default:current_timestamp"` // 建立時間，不為 null
-	UpdatedAt     time.Time `bun:",notnull,default:current_timestamp"` // 更新時間，不為 null
-	CoverImageUrl string    `bun:",notnull"`                           // 封面圖片，不為 null
-	Content       string    `bun:"content"`                            // 文章內容
-	IsDeleted     bool      `bun:",notnull,default:false"`             // 軟刪除欄位
-	NeedsRefresh  bool      `bun:",notnull,default:false"`             // 內容變更時觸發刷新
+	ID             uint       `bun:",pk,autoincrement,notnull"`          // 主鍵，自動遞增，不為 null
+	Title          string     `bun:",notnull"`                           // 標題，不為 null
+	CategoryID     uint       `bun:",notnull"`                           // 分類 ID，不為 null
+	IsPublished    bool       `bun:",notnull"`                           // 是否發佈，不為 null
+	Slug           string     `bun:",unique,notnull"`                    // 對 SEO 友善的唯一識別 slug
+	CreatedAt      time.Time  `bun:",notnull,default:current_timestamp"` // 建立時間，不為 null
+	UpdatedAt      time.Time  `bun:",notnull,default:current_timestamp"` // 更新時間，不為 null
+	CoverImageUrl  string     `bun:",notnull"`                           // 封面圖片，不為 null
+	Content        string     `bun:"content"`                            // 文章內容
+	IsDeleted      bool       `bun:",notnull,default:false"`             // 軟刪除欄位
+	NeedsRefresh   bool       `bun:",notnull,default:false"`             // 內容變更時觸發刷新
+	PublishAt      *time.Time `bun:"publish_at"`                         // 排程發佈時間，時間到由 batch 改為已發佈
+	UnpublishAt    *time.Time `bun:"unpublish_at"`                       // 排程下架時間，時間到由 batch 改為未發佈
+	DeletedAt      *time.Time `bun:"deleted_at"`                         // 移到垃圾桶的時間
+	Toc            []TocItem  `bun:"toc,type:jsonb"`                     // 由 header block 產生的目錄，儲存時計算
+	WordCount      int        `bun:"word_count,notnull,default:0"`       // 字數（中日韓文字逐字計算，英文以空白分隔計算）
+	ReadingMinutes int        `bun:"reading_minutes,notnull,default:0"`  // 預估閱讀分鐘數
+	Excerpt        string     `bun:"excerpt,notnull,default:''"`         // 手寫摘要，有填時取代自動產生的摘要
+	Summary        string     `bun:"summary,notnull,default:''"`         // 列表用的摘要，儲存時產生
+	Version        int        `bun:"version,notnull,default:1"`          // 每次修改加 1，用來偵測同時編輯的衝突
+
+	// SEO 設定，空白時由伺服器依文章內容決定（見 common/seo）
+	MetaDescription string `bun:"meta_description,notnull,default:''"` // meta description，預設使用 Summary
+	SocialTitle     string `bun:"social_title,notnull,default:''"`     // Open Graph / Twitter 標題，預設使用 Title
+	OgImageUrl      string `bun:"og_image_url,notnull,default:''"`     // Open Graph 圖片，預設使用 CoverImageUrl
+	CanonicalUrl    string `bun:"canonical_url,notnull,default:''"`    // canonical 網址，預設為前台文章網址
+	NoIndex         bool   `bun:"no_index,notnull,default:false"`      // 不讓搜尋引擎索引
+}
+
+// TocItem 文章目錄的一個標題，ID 與 HTML 輸出的 header id 相同
+type TocItem struct {
+	ID       string    `json:"id"`
+	Text     string    `json:"text"`
+	Level    int       `json:"level"`
+	Children []TocItem `json:"children"`
 }
@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// PostRelated 儲存文章時計算好的相關文章分數（分數越高越相關）
+type PostRelated struct {
+	bun.BaseModel `bun:"table:post_related"`
+
+	PostID        uint      `bun:",pk"`                                // 文章 ID
+	RelatedPostID uint      `bun:",pk"`                                // 相關文章 ID
+	Score         float64   `bun:",notnull"`                           // 分類距離與標題、內文用詞重疊程度的加權分數
+	UpdatedAt     time.Time `bun:",notnull,default:current_timestamp"` // 計算時間
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type PostRevision struct {
+	bun.BaseModel `bun:"table:post_revisions"`
+
+	ID            uint      `bun:",pk,autoincrement,notnull"`          // 主鍵，自動遞增
+	PostID        uint      `bun:",notnull"`                           // 所屬文章 ID
+	Title         string    `bun:",notnull"`                           // 當時的標題
+	Slug          string    `bun:",notnull"`                           // 當時的 slug
+	CategoryID    uint      `bun:",notnull"`                           // 當時的分類 ID
+	IsPublished   bool      `bun:",notnull"`                           // 當時是否發佈
+	CoverImageUrl string    `bun:",notnull"`                           // 當時的封面圖片
+	Content       string    `bun:"content"`                            // 當時的 Editor.js 內容
+	Excerpt       string    `bun:"excerpt,notnull,default:''"`         // 當時的手寫摘要
+	Source        string    `bun:",notnull"`                           // 'initial'、'create'、'update'、'restore' 或 'bulk'
+	RestoredFrom  *uint     `bun:"restored_from"`                      // 由哪一個版本還原而來
+	CreatedAt     time.Time `bun:",notnull,default:current_timestamp"` // 版本建立時間
+}
@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type PostSearchIndex struct {
+	bun.BaseModel `bun:"table:post_search_index"`
+
+	PostID    uint      `bun:",pk,notnull"`                        // 文章 ID
+	Title     string    `bun:",notnull"`                           // 文章標題
+	Body      string    `bun:"body,type:text,notnull"`             // 從 Editor.js 取出的純文字，用來產生搜尋片段
+	Document  string    `bun:"document,type:tsvector,notnull"`     // 標題（權重 A）與內文（權重 B）的 n-gram tsvector
+	UpdatedAt time.Time `bun:",notnull,default:current_timestamp"` // 索引更新時間
+}
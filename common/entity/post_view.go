@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// PostView 文章瀏覽紀錄（只新增不修改），由批次彙總成 PostViewDaily
+type PostView struct {
+	bun.BaseModel `bun:"table:post_views"`
+
+	ID          int64     `bun:",pk,autoincrement"`
+	PostID      uint      `bun:",notnull"`                           // 文章 ID
+	Fingerprint string    `bun:",notnull"`                           // 訪客 IP + User-Agent 的雜湊，不保存原始資料
+	ViewedAt    time.Time `bun:",notnull,default:current_timestamp"` // 瀏覽時間
+}
+
+// PostViewDaily 每篇文章每天的瀏覽數（批次從 PostView 彙總）
+type PostViewDaily struct {
+	bun.BaseModel `bun:"table:post_view_daily"`
+
+	PostID uint      `bun:",pk"`                // 文章 ID
+	Day    time.Time `bun:",pk,type:date"`      // 日期
+	Views  int       `bun:",notnull,default:0"` // 當天的瀏覽數
+}
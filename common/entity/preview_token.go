@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// PreviewToken 草稿預覽連結，token 本身不儲存，只記錄 ID 用來檢查到期與撤銷
+type PreviewToken struct {
+	bun.BaseModel `bun:"table:post_preview_tokens"`
+
+	ID        string     `bun:",pk"`                                // token ID（隨機字串，包含在 token 內）
+	PostID    uint       `bun:",notnull"`                           // 預覽的文章 ID
+	ExpiresAt time.Time  `bun:",notnull"`                           // 到期時間
+	RevokedAt *time.Time `bun:"revoked_at,nullzero"`                // 撤銷時間，未撤銷為 null
+	CreatedAt time.Time  `bun:",notnull,default:current_timestamp"` // 建立時間
+}
@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type SlugHistory struct {
+	bun.BaseModel `bun:"table:slug_histories"`
+
+	ID         uint      `bun:",pk,autoincrement,notnull"`          // 主鍵，自動遞增
+	EntityType string    `bun:",notnull,unique:entity_type_slug"`   // 'post' 或 'category'
+	EntityID   uint      `bun:",notnull"`                           // 文章或分類的 ID
+	Slug       string    `bun:",notnull,unique:entity_type_slug"`   // 曾經使用過的 slug（同類型內唯一）
+	CreatedAt  time.Time `bun:",notnull,default:current_timestamp"` // slug 被換掉的時間
+}
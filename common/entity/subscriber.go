@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// 訂閱者狀態
+const (
+	SubscriberStatusPending      = "pending"      // 已送出訂閱，尚未點確認信
+	SubscriberStatusConfirmed    = "confirmed"    // 已確認，會收到新文章通知
+	SubscriberStatusUnsubscribed = "unsubscribed" // 已取消訂閱
+)
+
+// Subscriber 電子報訂閱者（double opt-in：點確認信後才會收到通知）
+type Subscriber struct {
+	bun.BaseModel `bun:"table:subscribers"`
+
+	ID                 uint       `bun:",pk,autoincrement"`
+	Email              string     `bun:",notnull,unique"`                    // 小寫 email
+	Status             string     `bun:",notnull,default:'pending'"`         // pending、confirmed 或 unsubscribed
+	ConfirmationSentAt *time.Time `bun:"confirmation_sent_at,nullzero"`      // 最後一次寄確認信的時間（避免重複寄送）
+	ConfirmedAt        *time.Time `bun:"confirmed_at,nullzero"`              // 確認訂閱時間
+	UnsubscribedAt     *time.Time `bun:"unsubscribed_at,nullzero"`           // 取消訂閱時間
+	CreatedAt          time.Time  `bun:",notnull,default:current_timestamp"` // 第一次訂閱時間
+	UpdatedAt          time.Time  `bun:",notnull,default:current_timestamp"`
+}
+
+// PostNotification 文章第一次發佈時寄給訂閱者的通知，每篇文章只會有一筆（用來確保只寄一次）
+type PostNotification struct {
+	bun.BaseModel `bun:"table:post_notifications"`
+
+	PostID         uint       `bun:",pk"`                                // 文章 ID
+	ClaimedAt      time.Time  `bun:",notnull,default:current_timestamp"` // 文章發佈、取得寄送資格的時間
+	SentAt         *time.Time `bun:"sent_at,nullzero"`                   // 開始寄送的時間，null 表示還沒寄
+	RecipientCount int        `bun:",notnull,default:0"`                 // 成功寄出的數量
+}
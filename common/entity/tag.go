@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type Tag struct {
+	bun.BaseModel `bun:"table:tags"`
+
+	ID        uint      `bun:",pk,autoincrement,notnull"`          // 主鍵，不為 null
+	Name      string    `bun:",notnull"`                           // 標籤名稱，不為 null
+	Slug      string    `bun:",unique,notnull"`                    // 標籤的 URL slug
+	CreatedAt time.Time `bun:",notnull,default:current_timestamp"` // 建立時間，不為 null
+	UpdatedAt time.Time `bun:",notnull,default:current_timestamp"` // 更新時間，不為 null
+}
+
+// PostTag 文章與標籤的多對多關聯
+type PostTag struct {
+	bun.BaseModel `bun:"table:post_tags"`
+
+	PostID uint `bun:",pk"` // 文章 ID
+	TagID  uint `bun:",pk"` // 標籤 ID
+}
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Webhook 傳送狀態
+const (
+	WebhookDeliveryPending   = "pending"   // 等待傳送或等待重試
+	WebhookDeliverySucceeded = "succeeded" // 對方回應 2xx
+	WebhookDeliveryFailed    = "failed"    // 重試次數用完仍失敗
+)
+
+// WebhookSubscription 後台設定的 webhook，內容異動時依 Events 過濾後送出
+type WebhookSubscription struct {
+	bun.BaseModel `bun:"table:webhook_subscriptions"`
+
+	ID        uint      `bun:",pk,autoincrement"`
+	Name      string    `bun:",notnull"`                           // 顯示名稱，例如 Slack 通知
+	URL       string    `bun:"url,notnull"`                        // 接收事件的網址
+	Secret    string    `bun:",notnull"`                           // 簽章用的金鑰（X-Webhook-Signature）
+	Events    []string  `bun:",array,notnull"`                     // 訂閱的事件，例如 post.published、category.*、*
+	IsActive  bool      `bun:",notnull,default:true"`              // 停用時不會再產生新的傳送
+	CreatedAt time.Time `bun:",notnull,default:current_timestamp"` // 建立時間
+	UpdatedAt time.Time `bun:",notnull,default:current_timestamp"` // 更新時間
+}
+
+// WebhookDelivery 一次事件對一個 webhook 的傳送紀錄，失敗時依 NextAttemptAt 重試
+type WebhookDelivery struct {
+	bun.BaseModel `bun:"table:webhook_deliveries"`
+
+	ID             int64      `bun:",pk,autoincrement"`
+	SubscriptionID uint       `bun:",notnull"`                           // webhook ID
+	Event          string     `bun:",notnull"`                           // 事件名稱
+	Payload        string     `bun:",type:jsonb,notnull"`                // 送出的 JSON 內容（重試時內容不變）
+	Status         string     `bun:",notnull,default:'pending'"`         // pending、succeeded 或 failed
+	Attempts       int        `bun:",notnull,default:0"`                 // 已嘗試次數
+	NextAttemptAt  *time.Time `bun:"next_attempt_at"`                    // 下次嘗試時間，成功或放棄後為 null
+	ResponseStatus int        `bun:",notnull,default:0"`                 // 最後一次的 HTTP 狀態碼，連線失敗為 0
+	LastError      string     `bun:",notnull,default:''"`                // 最後一次的錯誤訊息
+	DeliveredAt    *time.Time `bun:"delivered_at"`                       // 成功送達的時間
+	CreatedAt      time.Time  `bun:",notnull,default:current_timestamp"` // 事件發生時間
+	UpdatedAt      time.Time  `bun:",notnull,default:current_timestamp"` // 最後一次嘗試時間
+}
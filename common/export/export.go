@@ -0,0 +1,302 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/utils"
+
+	"github.com/uptrace/bun"
+	"gopkg.in/yaml.v3"
+)
+
+// 每次從資料庫讀取的文章數，避免一次把所有內容載入記憶體
+const postBatchSize = 100
+
+// Summary 匯出結果
+type Summary struct {
+	Posts    int      `json:"posts"`
+	Images   int      `json:"images"`
+	Warnings []string `json:"warnings"` // 內容無法轉換等不影響整體匯出的問題
+}
+
+// 文章 front matter，title/slug/category/tags/cover/excerpt/published 與 SEO 欄位和 Markdown 匯入相同，可以直接再匯入
+type postFrontMatter struct {
+	ID           uint       `yaml:"id"`
+	Title        string     `yaml:"title"`
+	Slug         string     `yaml:"slug"`
+	Category     string     `yaml:"category"`     // 分類 slug
+	CategoryPath string     `yaml:"categoryPath"` // 從最上層到所屬分類的 slug，以 / 分隔
+	Tags         []string   `yaml:"tags"`
+	Cover        string     `yaml:"cover,omitempty"`
+	Excerpt      string     `yaml:"excerpt,omitempty"`
+	Published    bool       `yaml:"published"`
+	PublishAt    *time.Time `yaml:"publishAt,omitempty"`
+	UnpublishAt  *time.Time `yaml:"unpublishAt,omitempty"`
+	Deleted      bool       `yaml:"deleted,omitempty"`
+	DeletedAt    *time.Time `yaml:"deletedAt,omitempty"`
+	CreatedAt    time.Time  `yaml:"createdAt"`
+	UpdatedAt    time.Time  `yaml:"updatedAt"`
+
+	MetaDescription string `yaml:"metaDescription,omitempty"`
+	SocialTitle     string `yaml:"socialTitle,omitempty"`
+	OgImage         string `yaml:"ogImage,omitempty"`
+	CanonicalUrl    string `yaml:"canonicalUrl,omitempty"`
+	NoIndex         bool   `yaml:"noindex,omitempty"`
+}
+
+type imageManifestEntry struct {
+	URL       string    `json:"url"`
+	PostID    uint      `json:"postId"`
+	Type      string    `json:"type"`
+	Status    string    `json:"status"`
+	IsDeleted bool      `json:"isDeleted"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WriteArchive 把所有文章、關於我與圖片清單寫成 zip
+//
+//	posts/<slug>.md  已發佈與未發佈的文章
+//	trash/<slug>.md  垃圾桶內的文章
+//	about.md         關於我
+//	images.json      所有 images 紀錄（含網址與所屬文章）
+func WriteArchive(ctx context.Context, db bun.IDB, w io.Writer) (Summary, error) {
+	summary := Summary{Warnings: []string{}}
+	zw := zip.NewWriter(w)
+
+	categoryPaths, err := loadCategoryPaths(ctx, db)
+	if err != nil {
+		return summary, err
+	}
+	postTags, err := loadPostTags(ctx, db)
+	if err != nil {
+		return summary, err
+	}
+
+	var lastID uint
+	for {
+		var posts []entity.Post
+		err := db.NewSelect().
+			Model(&posts).
+			Where("id > ?", lastID).
+			Order("id ASC").
+			Limit(postBatchSize).
+			Scan(ctx)
+		if err != nil {
+			return summary, fmt.Errorf("讀取文章失敗：%w", err)
+		}
+		if len(posts) == 0 {
+			break
+		}
+
+		for _, post := range posts {
+			lastID = post.ID
+
+			path := categoryPaths[post.CategoryID]
+			fm := postFrontMatter{
+				ID:           post.ID,
+				Title:        post.Title,
+				Slug:         post.Slug,
+				Category:     path[strings.LastIndex(path, "/")+1:],
+				CategoryPath: path,
+				Tags:         postTags[post.ID],
+				Cover:        post.CoverImageUrl,
+				Excerpt:      post.Excerpt,
+				Published:    post.IsPublished,
+				PublishAt:    post.PublishAt,
+				UnpublishAt:  post.UnpublishAt,
+				Deleted:      post.IsDeleted,
+				DeletedAt:    post.DeletedAt,
+				CreatedAt:    post.CreatedAt,
+				UpdatedAt:    post.UpdatedAt,
+
+				MetaDescription: post.MetaDescription,
+				SocialTitle:     post.SocialTitle,
+				OgImage:         post.OgImageUrl,
+				CanonicalUrl:    post.CanonicalUrl,
+				NoIndex:         post.NoIndex,
+			}
+			if fm.Tags == nil {
+				fm.Tags = []string{}
+			}
+
+			body, ok := contentToMarkdown(post.Content)
+			if !ok {
+				summary.Warnings = append(summary.Warnings, fmt.Sprintf("文章 #%d（%s）內容無法解析，以原始 JSON 保留", post.ID, post.Slug))
+			}
+
+			dir := "posts"
+			if post.IsDeleted {
+				dir = "trash"
+			}
+			if err := writeMarkdown(zw, fmt.Sprintf("%s/%s.md", dir, post.Slug), fm, body); err != nil {
+				return summary, err
+			}
+			summary.Posts++
+		}
+	}
+
+	if err := writeAboutMe(ctx, db, zw, &summary); err != nil {
+		return summary, err
+	}
+
+	count, err := writeImageManifest(ctx, db, zw)
+	if err != nil {
+		return summary, err
+	}
+	summary.Images = count
+
+	if err := zw.Close(); err != nil {
+		return summary, fmt.Errorf("寫入 zip 失敗：%w", err)
+	}
+	return summary, nil
+}
+
+func writeAboutMe(ctx context.Context, db bun.IDB, zw *zip.Writer, summary *Summary) error {
+	var about entity.AboutMe
+	err := db.NewSelect().
+		Model(&about).
+		Order("updated_at DESC").
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		// 還沒有關於我的內容時就不輸出
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("讀取關於我失敗：%w", err)
+	}
+
+	body, ok := contentToMarkdown(about.HtmlContent)
+	if !ok {
+		summary.Warnings = append(summary.Warnings, "關於我內容無法解析，以原始 JSON 保留")
+	}
+	fm := struct {
+		Title     string    `yaml:"title"`
+		UpdatedAt time.Time `yaml:"updatedAt"`
+	}{Title: "關於我", UpdatedAt: about.UpdatedAt}
+	return writeMarkdown(zw, "about.md", fm, body)
+}
+
+func writeImageManifest(ctx context.Context, db bun.IDB, zw *zip.Writer) (int, error) {
+	var images []entity.Image
+	err := db.NewSelect().
+		Model(&images).
+		Order("post_id ASC", "created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("讀取圖片失敗：%w", err)
+	}
+
+	manifest := make([]imageManifestEntry, 0, len(images))
+	for _, img := range images {
+		manifest = append(manifest, imageManifestEntry{
+			URL:       img.URL,
+			PostID:    img.PostID,
+			Type:      img.Type,
+			Status:    img.Status,
+			IsDeleted: img.IsDeleted,
+			CreatedAt: img.CreatedAt,
+		})
+	}
+
+	f, err := zw.Create("images.json")
+	if err != nil {
+		return 0, fmt.Errorf("寫入 zip 失敗：%w", err)
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return 0, fmt.Errorf("寫入圖片清單失敗：%w", err)
+	}
+	return len(manifest), nil
+}
+
+// 分類 ID → 從最上層開始的 slug 路徑（例如 tech/golang）
+func loadCategoryPaths(ctx context.Context, db bun.IDB) (map[uint]string, error) {
+	var categories []entity.Category
+	if err := db.NewSelect().Model(&categories).Scan(ctx); err != nil {
+		return nil, fmt.Errorf("讀取分類失敗：%w", err)
+	}
+
+	byID := make(map[uint]entity.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	paths := make(map[uint]string, len(categories))
+	for _, c := range categories {
+		slugs := []string{}
+		seen := make(map[uint]bool)
+		for cur, ok := c, true; ok && !seen[cur.ID]; {
+			seen[cur.ID] = true
+			slugs = append([]string{cur.Slug}, slugs...)
+			if cur.Parent == nil {
+				break
+			}
+			cur, ok = byID[*cur.Parent]
+		}
+		paths[c.ID] = strings.Join(slugs, "/")
+	}
+	return paths, nil
+}
+
+// 文章 ID → 標籤 slug
+func loadPostTags(ctx context.Context, db bun.IDB) (map[uint][]string, error) {
+	var rows []struct {
+		PostID uint   `bun:"post_id"`
+		Slug   string `bun:"slug"`
+	}
+	err := db.NewSelect().
+		TableExpr("post_tags AS pt").
+		ColumnExpr("pt.post_id, tag.slug").
+		Join("JOIN tags AS tag ON tag.id = pt.tag_id").
+		OrderExpr("pt.post_id ASC, tag.slug ASC").
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, fmt.Errorf("讀取標籤失敗：%w", err)
+	}
+
+	result := make(map[uint][]string)
+	for _, row := range rows {
+		result[row.PostID] = append(result[row.PostID], row.Slug)
+	}
+	return result, nil
+}
+
+// 內容無法解析時以 JSON 程式碼區塊保留原文，ok 回傳 false
+func contentToMarkdown(content string) (string, bool) {
+	if strings.TrimSpace(content) == "" {
+		return "", true
+	}
+	md, err := utils.EditorJSToMarkdown(content)
+	if err != nil {
+		return "```json\n" + content + "\n```\n", false
+	}
+	return md, true
+}
+
+func writeMarkdown(zw *zip.Writer, name string, frontMatter interface{}, body string) error {
+	fm, err := yaml.Marshal(frontMatter)
+	if err != nil {
+		return fmt.Errorf("產生 front matter 失敗（%s）：%w", name, err)
+	}
+
+	f, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("寫入 zip 失敗：%w", err)
+	}
+	_, err = io.WriteString(f, "---\n"+string(fm)+"---\n\n"+body)
+	if err != nil {
+		return fmt.Errorf("寫入 zip 失敗（%s）：%w", name, err)
+	}
+	return nil
+}
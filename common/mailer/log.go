@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogMailer 不真的寄信，把信件內容寫到檔案（Path 為空時寫到 stdout），給本地開發使用
+type LogMailer struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func NewLogMailer(path string) *LogMailer {
+	return &LogMailer{Path: strings.TrimSpace(path)}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var w io.Writer = os.Stdout
+	if m.Path != "" {
+		f, err := os.OpenFile(m.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📧 ===== " + time.Now().Format(time.DateTime) + " =====\n")
+	sb.WriteString("To: " + msg.To + "\n")
+	sb.WriteString("Subject: " + msg.Subject + "\n")
+	keys := make([]string, 0, len(msg.Headers))
+	for key := range msg.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		sb.WriteString(key + ": " + msg.Headers[key] + "\n")
+	}
+	sb.WriteString("\n" + msg.Text + "\n\n")
+
+	_, err := fmt.Fprint(w, sb.String())
+	return err
+}
@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Message 要寄出的信件，HTML 可以省略
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string // 額外的 header，例如 List-Unsubscribe
+}
+
+// Mailer 寄信方式，正式環境用 SMTP，本地開發用 LogMailer
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv 依 MAILER 環境變數選擇寄信方式：smtp 使用 SMTP_* 設定，其他（預設）只寫到 log
+func FromEnv() Mailer {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("MAILER"))) {
+	case "smtp":
+		m, err := NewSMTPMailerFromEnv()
+		if err != nil {
+			fmt.Printf("⚠️ SMTP 設定不完整，改用 log 寄信：%v\n", err)
+			return NewLogMailer(os.Getenv("MAILER_LOG_FILE"))
+		}
+		return m
+	default:
+		return NewLogMailer(os.Getenv("MAILER_LOG_FILE"))
+	}
+}
@@ -5,6 +5,7 @@ import "fmt"
 type AppError struct {
 	Code    string
 	Message string
+	Data    interface{} // 可選，隨錯誤一起回傳給前端的資料
 }
 
 // 定義錯誤
@@ -13,8 +14,9 @@ var (
 	ErrOK = New("OK", "成功")
 
 	// ❌ 請求錯誤（輸入錯、格式錯、驗證錯）
-	ErrBadRequest = New("ErrBadRequest", "請求格式錯誤或參數無效")
-	ErrValidation = New("ErrValidation", "輸入驗證失敗，請確認欄位格式與內容")
+	ErrBadRequest      = New("ErrBadRequest", "請求格式錯誤或參數無效")
+	ErrValidation      = New("ErrValidation", "輸入驗證失敗，請確認欄位格式與內容")
+	ErrTooManyRequests = New("ErrTooManyRequests", "請求過於頻繁，請稍後再試")
 
 	// 🔐 權限相關
 	ErrUnauthorized = New("ErrUnauthorized", "未經授權的存取，請先登入或提供有效憑證")
@@ -22,6 +24,11 @@ var (
 
 	// 📦 資源查無（文章、使用者、檔案不存在）
 	ErrNotFound = New("ErrNotFound", "找不到請求的資源")
+	ErrMoved    = New("ErrMoved", "資源已移至新的網址")
+
+	// 🔁 同時編輯衝突（資料已被其他人修改）
+	ErrConflict        = New("ErrConflict", "資料已被修改，請重新載入最新版本後再儲存")
+	ErrVersionRequired = New("ErrVersionRequired", "缺少版本號，請帶上 If-Match header 或 version 欄位")
 
 	// 🧱 資料層錯誤（DB 失敗、資料有問題）
 	ErrDB        = New("ErrDB", "資料庫操作失敗")
@@ -41,6 +48,11 @@ func (e *AppError) Error() string {
 	return fmt.Sprintf("code=%s, message=%s", e.Code, e.Message)
 }
 
+// WithData 回傳附帶資料的錯誤副本，不會修改共用的錯誤定義
+func (e *AppError) WithData(data interface{}) *AppError {
+	return &AppError{Code: e.Code, Message: e.Message, Data: data}
+}
+
 func New(code, message string) *AppError {
 	return &AppError{Code: code, Message: message}
 }
@@ -20,6 +20,7 @@ func ExceptionMiddleware() gin.HandlerFunc {
 				c.JSON(http.StatusBadRequest, model.APIResponseAny{
 					Code:    appErr.Code,
 					Message: appErr.Message,
+					Data:    appErr.Data,
 				})
 			} else {
 				c.JSON(http.StatusInternalServerError, model.APIResponseAny{
@@ -47,23 +47,7 @@ func VerifySignedHeaders() gin.HandlerFunc {
 		// 支援雙組 token 驗證（輪替用）
 		// 將來要更新worker與apigw之間的token時，因為不能直接把原本的token拿掉會發生問題，所以這邊使用輪替的方式
 		// 目前先不給SIGNING_SECRET_SECONDARY的環境變數，現在不給也不會發生問題
-		secrets := []string{
-			os.Getenv("SIGNING_SECRET"),
-			os.Getenv("SIGNING_SECRET_SECONDARY"),
-		}
-
-		valid := false
-		for _, secret := range secrets {
-			if secret == "" {
-				continue
-			}
-			if generateHMAC(timestampStr, secret) == signature {
-				valid = true
-				break
-			}
-		}
-
-		if !valid {
+		if !VerifyHMAC(timestampStr, signature, "SIGNING_SECRET", "SIGNING_SECRET_SECONDARY") {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "簽章不正確"})
 			return
 		}
@@ -72,6 +56,29 @@ func VerifySignedHeaders() gin.HandlerFunc {
 	}
 }
 
+// SignHMAC 用主要金鑰（secretEnv 環境變數）簽章，金鑰未設定時回傳 false
+func SignHMAC(message, secretEnv string) (string, bool) {
+	secret := os.Getenv(secretEnv)
+	if secret == "" {
+		return "", false
+	}
+	return generateHMAC(message, secret), true
+}
+
+// VerifyHMAC 依序用主、備用金鑰驗證簽章，任一組通過即可（輪替金鑰時新舊簽章都有效）
+func VerifyHMAC(message, signature string, secretEnvs ...string) bool {
+	for _, env := range secretEnvs {
+		secret := os.Getenv(env)
+		if secret == "" {
+			continue
+		}
+		if hmac.Equal([]byte(generateHMAC(message, secret)), []byte(signature)) {
+			return true
+		}
+	}
+	return false
+}
+
 func generateHMAC(message, secret string) string {
 	mac := hmac.New(sha256.New, []byte(secret))
 	mac.Write([]byte(message))
@@ -0,0 +1,6 @@
+package model
+
+// ConflictData 寫入時版本號已過期，隨 ErrConflict 一起回傳目前的版本號
+type ConflictData struct {
+	CurrentVersion int `json:"currentVersion"`
+}
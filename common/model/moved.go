@@ -0,0 +1,7 @@
+package model
+
+// MovedData 資源的 slug 已變更時，隨 ErrMoved 一起回傳的新位置（前台 worker 可據此回 301）
+type MovedData struct {
+	Type          string `json:"type"`          // 'post' 或 'category'
+	CanonicalSlug string `json:"canonicalSlug"` // 目前的 slug
+}
@@ -0,0 +1,200 @@
+package newsletter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/mailer"
+	"blog-backend/common/seo"
+
+	"github.com/uptrace/bun"
+)
+
+// 前台確認、取消訂閱頁面的網址，頁面再以 token 呼叫 /api/post/newsletter/confirm、/unsubscribe
+// 取消訂閱網址也放在 List-Unsubscribe header，Worker 需要把這個網址的 POST（一鍵取消訂閱）轉給 API
+func ConfirmURL(token string) string {
+	return seo.SiteBaseURL() + "/newsletter/confirm?token=" + url.QueryEscape(token)
+}
+
+func UnsubscribeURL(token string) string {
+	return seo.SiteBaseURL() + "/newsletter/unsubscribe?token=" + url.QueryEscape(token)
+}
+
+// SendConfirmation 寄出確認訂閱的信
+func SendConfirmation(ctx context.Context, m mailer.Mailer, subscriber entity.Subscriber) error {
+	token, err := IssueToken(PurposeConfirm, subscriber, time.Now().Add(ConfirmTokenTTL))
+	if err != nil {
+		return err
+	}
+	link := ConfirmURL(token)
+	name := siteName()
+
+	return m.Send(ctx, mailer.Message{
+		To:      subscriber.Email,
+		Subject: fmt.Sprintf("請確認訂閱「%s」", name),
+		Text: fmt.Sprintf("感謝你訂閱「%s」！\n\n請在 %d 小時內點擊下面的連結完成訂閱：\n%s\n\n如果你沒有訂閱，請忽略這封信。\n",
+			name, int(ConfirmTokenTTL.Hours()), link),
+		HTML: fmt.Sprintf(`<p>感謝你訂閱「%s」！</p><p>請在 %d 小時內點擊下面的連結完成訂閱：</p><p><a href="%s">確認訂閱</a></p><p>如果你沒有訂閱，請忽略這封信。</p>`,
+			html.EscapeString(name), int(ConfirmTokenTTL.Hours()), html.EscapeString(link)),
+	})
+}
+
+// ClaimPostNotification 文章從未發佈變成發佈時呼叫（與發佈在同一個交易中）
+// 每篇文章只會成功一次，回傳 true 表示這次取得寄送資格，提交後再呼叫 SendPostNotification
+func ClaimPostNotification(ctx context.Context, db bun.IDB, postID uint) (bool, error) {
+	claim := entity.PostNotification{PostID: postID, ClaimedAt: time.Now()}
+	res, err := db.NewInsert().
+		Model(&claim).
+		On("CONFLICT (post_id) DO NOTHING").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// SendPostNotification 把新文章寄給所有已確認的訂閱者，回傳寄出的數量
+// 先把通知標記為已寄送再開始寄信，同時呼叫或重新執行都不會重複寄送；文章目前不是發佈狀態時先不寄
+func SendPostNotification(ctx context.Context, db bun.IDB, m mailer.Mailer, postID uint) (int, error) {
+	var post entity.Post
+	err := db.NewSelect().
+		Model(&post).
+		Column("id", "title", "slug", "summary").
+		Where("id = ?", postID).
+		Where("is_published = TRUE").
+		Where("is_deleted = FALSE").
+		Scan(ctx)
+	if err != nil {
+		// 找不到（已下架或刪除）時保留通知，下次發佈再寄
+		return 0, ignoreNoRows(err)
+	}
+	// 沒有金鑰就無法產生取消訂閱連結，在標記為已寄送之前先檢查
+	if os.Getenv(secretEnv) == "" {
+		return 0, ErrSecretNotSet
+	}
+
+	var claimed []uint
+	err = db.NewUpdate().
+		Model((*entity.PostNotification)(nil)).
+		Set("sent_at = NOW()").
+		Where("post_id = ?", postID).
+		Where("sent_at IS NULL").
+		Returning("post_id").
+		Scan(ctx, &claimed)
+	if err != nil {
+		return 0, err
+	}
+	if len(claimed) == 0 {
+		return 0, nil
+	}
+
+	var subscribers []entity.Subscriber
+	err = db.NewSelect().
+		Model(&subscribers).
+		Where("status = ?", entity.SubscriberStatusConfirmed).
+		OrderExpr("id ASC").
+		Scan(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, subscriber := range subscribers {
+		msg, err := postNotificationMessage(post, subscriber)
+		if err != nil {
+			return sent, err
+		}
+		if err := m.Send(ctx, msg); err != nil {
+			fmt.Printf("⚠️ 新文章通知寄送失敗：文章 ID=%d，%s，錯誤：%v\n", post.ID, subscriber.Email, err)
+			continue
+		}
+		sent++
+	}
+
+	_, err = db.NewUpdate().
+		Model((*entity.PostNotification)(nil)).
+		Set("recipient_count = ?", sent).
+		Where("post_id = ?", postID).
+		Exec(ctx)
+	if err != nil {
+		return sent, err
+	}
+
+	fmt.Printf("📧 新文章通知已寄出：文章 ID=%d，共 %d 位訂閱者\n", post.ID, sent)
+	return sent, nil
+}
+
+// SendPendingNotifications 寄出已取得資格但還沒寄送的通知（例如發佈後程式中斷），回傳寄出通知的文章數
+func SendPendingNotifications(ctx context.Context, db bun.IDB, m mailer.Mailer) (int, error) {
+	var postIDs []uint
+	err := db.NewSelect().
+		Model((*entity.PostNotification)(nil)).
+		Column("post_id").
+		Where("sent_at IS NULL").
+		OrderExpr("claimed_at ASC").
+		Scan(ctx, &postIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, postID := range postIDs {
+		sent, err := SendPostNotification(ctx, db, m, postID)
+		if err != nil {
+			return count, err
+		}
+		if sent > 0 {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func postNotificationMessage(post entity.Post, subscriber entity.Subscriber) (mailer.Message, error) {
+	token, err := IssueToken(PurposeUnsubscribe, subscriber, time.Time{})
+	if err != nil {
+		return mailer.Message{}, err
+	}
+	unsubscribe := UnsubscribeURL(token)
+	link := seo.PostURL(post.Slug)
+	name := siteName()
+
+	return mailer.Message{
+		To:      subscriber.Email,
+		Subject: fmt.Sprintf("「%s」新文章：%s", name, post.Title),
+		Text: fmt.Sprintf("%s\n\n%s\n\n閱讀全文：%s\n\n不想再收到通知？取消訂閱：%s\n",
+			post.Title, post.Summary, link, unsubscribe),
+		HTML: fmt.Sprintf(`<h2><a href="%s">%s</a></h2><p>%s</p><p><a href="%s">閱讀全文</a></p><hr><p><small>不想再收到通知？<a href="%s">取消訂閱</a></small></p>`,
+			html.EscapeString(link), html.EscapeString(post.Title), html.EscapeString(post.Summary),
+			html.EscapeString(link), html.EscapeString(unsubscribe)),
+		// 一鍵取消訂閱（RFC 8058）
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribe + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
+
+// 信件中使用的網站名稱（SITE_NAME）
+func siteName() string {
+	if name := strings.TrimSpace(os.Getenv("SITE_NAME")); name != "" {
+		return name
+	}
+	return "部落格"
+}
+
+func ignoreNoRows(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,96 @@
+package newsletter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/middleware"
+
+	"github.com/uptrace/bun"
+)
+
+// 訂閱 token 的格式：<用途>.<訂閱者 ID>.<到期時間 unix，0 表示不會過期>.<HMAC>
+// 簽章內容包含訂閱者的 email，訂閱者重新建立後舊的 token 就會失效
+const (
+	secretEnv          = "NEWSLETTER_SIGNING_SECRET"
+	secondarySecretEnv = "NEWSLETTER_SIGNING_SECRET_SECONDARY"
+)
+
+// token 用途
+const (
+	PurposeConfirm     = "confirm"     // 確認訂閱
+	PurposeUnsubscribe = "unsubscribe" // 取消訂閱
+)
+
+// 確認信的有效時間
+const ConfirmTokenTTL = 48 * time.Hour
+
+var (
+	ErrSecretNotSet = errors.New("NEWSLETTER_SIGNING_SECRET 未設定")
+	ErrInvalidToken = errors.New("連結無效")
+	ErrExpiredToken = errors.New("連結已過期")
+)
+
+// IssueToken 產生訂閱者的 token，expiresAt 為零值時不會過期（取消訂閱連結）
+func IssueToken(purpose string, subscriber entity.Subscriber, expiresAt time.Time) (string, error) {
+	exp := int64(0)
+	if !expiresAt.IsZero() {
+		exp = expiresAt.Unix()
+	}
+
+	payload := fmt.Sprintf("%s.%d.%d", purpose, subscriber.ID, exp)
+	signature, ok := middleware.SignHMAC(signingMessage(payload, subscriber.Email), secretEnv)
+	if !ok {
+		return "", ErrSecretNotSet
+	}
+	return payload + "." + signature, nil
+}
+
+// VerifyToken 檢查 token 的用途、簽章與到期時間，回傳對應的訂閱者
+func VerifyToken(ctx context.Context, db bun.IDB, purpose, token string) (entity.Subscriber, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 4 || parts[0] != purpose {
+		return entity.Subscriber{}, ErrInvalidToken
+	}
+
+	subscriberID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return entity.Subscriber{}, ErrInvalidToken
+	}
+	exp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return entity.Subscriber{}, ErrInvalidToken
+	}
+
+	var subscriber entity.Subscriber
+	err = db.NewSelect().
+		Model(&subscriber).
+		Where("id = ?", subscriberID).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Subscriber{}, ErrInvalidToken
+	} else if err != nil {
+		return entity.Subscriber{}, err
+	}
+
+	payload := strings.Join(parts[:3], ".")
+	if !middleware.VerifyHMAC(signingMessage(payload, subscriber.Email), parts[3], secretEnv, secondarySecretEnv) {
+		return entity.Subscriber{}, ErrInvalidToken
+	}
+	if exp != 0 && !time.Now().Before(time.Unix(exp, 0)) {
+		return entity.Subscriber{}, ErrExpiredToken
+	}
+	return subscriber, nil
+}
+
+// 加上用途前綴，避免其他地方用同一把金鑰簽出的內容被當成訂閱 token
+func signingMessage(payload, email string) string {
+	return "newsletter:" + payload + ":" + email
+}
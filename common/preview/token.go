@@ -0,0 +1,124 @@
+package preview
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/middleware"
+
+	"github.com/uptrace/bun"
+)
+
+// 預覽 token 的格式：<token ID>.<文章 ID>.<到期時間 unix>.<HMAC>
+// 簽章方式與 middleware.VerifySignedHeaders 相同，金鑰支援主、備用兩組輪替
+const (
+	secretEnv          = "PREVIEW_SIGNING_SECRET"
+	secondarySecretEnv = "PREVIEW_SIGNING_SECRET_SECONDARY"
+)
+
+var (
+	ErrSecretNotSet = errors.New("PREVIEW_SIGNING_SECRET 未設定")
+	ErrInvalidToken = errors.New("預覽連結無效")
+	ErrExpiredToken = errors.New("預覽連結已過期")
+	ErrRevokedToken = errors.New("預覽連結已撤銷")
+)
+
+// Claims token 內容
+type Claims struct {
+	ID        string
+	PostID    uint
+	ExpiresAt time.Time
+}
+
+// Issue 產生預覽 token，並在資料庫記錄 token ID（用來撤銷）
+func Issue(ctx context.Context, db bun.IDB, postID uint, expiresAt time.Time) (string, entity.PreviewToken, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", entity.PreviewToken{}, err
+	}
+	id := hex.EncodeToString(buf)
+
+	payload := fmt.Sprintf("%s.%d.%d", id, postID, expiresAt.Unix())
+	signature, ok := middleware.SignHMAC(signingMessage(payload), secretEnv)
+	if !ok {
+		return "", entity.PreviewToken{}, ErrSecretNotSet
+	}
+
+	record := entity.PreviewToken{
+		ID:        id,
+		PostID:    postID,
+		ExpiresAt: time.Unix(expiresAt.Unix(), 0),
+		CreatedAt: time.Now(),
+	}
+	if _, err := db.NewInsert().Model(&record).Exec(ctx); err != nil {
+		return "", entity.PreviewToken{}, err
+	}
+	return payload + "." + signature, record, nil
+}
+
+// Parse 檢查簽章與到期時間（不查資料庫）
+func Parse(token string, now time.Time) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 || parts[0] == "" {
+		return Claims{}, ErrInvalidToken
+	}
+
+	payload := strings.Join(parts[:3], ".")
+	if !middleware.VerifyHMAC(signingMessage(payload), parts[3], secretEnv, secondarySecretEnv) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	postID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	exp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	claims := Claims{ID: parts[0], PostID: uint(postID), ExpiresAt: time.Unix(exp, 0)}
+	if !now.Before(claims.ExpiresAt) {
+		return Claims{}, ErrExpiredToken
+	}
+	return claims, nil
+}
+
+// Verify 檢查簽章、到期時間，並確認 token 沒有被撤銷
+func Verify(ctx context.Context, db bun.IDB, token string) (Claims, error) {
+	claims, err := Parse(token, time.Now())
+	if err != nil {
+		return Claims{}, err
+	}
+
+	var record entity.PreviewToken
+	err = db.NewSelect().
+		Model(&record).
+		Where("id = ?", claims.ID).
+		Where("post_id = ?", claims.PostID).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		// 簽章正確但紀錄不存在：文章已永久刪除
+		return Claims{}, ErrRevokedToken
+	} else if err != nil {
+		return Claims{}, err
+	}
+	if record.RevokedAt != nil {
+		return Claims{}, ErrRevokedToken
+	}
+	return claims, nil
+}
+
+// 加上用途前綴，避免其他地方用同一把金鑰簽出的內容被當成預覽 token
+func signingMessage(payload string) string {
+	return "preview:" + payload
+}
@@ -0,0 +1,47 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/utils"
+
+	"github.com/uptrace/bun"
+)
+
+// IndexPost 建立或更新文章的搜尋索引；傳入交易時會跟文章異動一起提交
+func IndexPost(ctx context.Context, db bun.IDB, postID uint, title, content string) error {
+	body := utils.ExtractPlainTextFromEditorJS(content)
+
+	row := entity.PostSearchIndex{
+		PostID:    postID,
+		Title:     title,
+		Body:      body,
+		UpdatedAt: time.Now(),
+	}
+	_, err := db.NewInsert().
+		Model(&row).
+		Value("document", "setweight(to_tsvector('simple', ?), 'A') || setweight(to_tsvector('simple', ?), 'B')",
+			strings.Join(Tokenize(title), " "),
+			strings.Join(Tokenize(body), " "),
+		).
+		On("CONFLICT (post_id) DO UPDATE").
+		Set("title = EXCLUDED.title").
+		Set("body = EXCLUDED.body").
+		Set("document = EXCLUDED.document").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("NULL").
+		Exec(ctx)
+	return err
+}
+
+// RemovePost 移除文章的搜尋索引
+func RemovePost(ctx context.Context, db bun.IDB, postID interface{}) error {
+	_, err := db.NewDelete().
+		Model((*entity.PostSearchIndex)(nil)).
+		Where("post_id = ?", postID).
+		Exec(ctx)
+	return err
+}
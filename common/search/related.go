@@ -0,0 +1,312 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"blog-backend/common/entity"
+
+	"github.com/uptrace/bun"
+)
+
+// 相關文章的分數 = 分類距離 × 0.4 + 標題用詞重疊 × 0.35 + 內文用詞重疊 × 0.25
+const (
+	relatedCategoryWeight = 0.4
+	relatedTitleWeight    = 0.35
+	relatedBodyWeight     = 0.25
+
+	// 分數低於這個值的文章不列為相關文章
+	relatedMinScore = 0.05
+)
+
+// RelatedPostLimit 每篇文章保留的相關文章數
+const RelatedPostLimit = 12
+
+// 計算相關文章用的文章資料（來自搜尋索引的純文字）
+type relatedDoc struct {
+	PostID     uint   `bun:"post_id"`
+	CategoryID uint   `bun:"category_id"`
+	Title      string `bun:"title"`
+	Body       string `bun:"body"`
+
+	titleTerms map[string]float64
+	bodyTerms  map[string]float64
+}
+
+type relatedScore struct {
+	postID uint
+	score  float64
+}
+
+// RefreshRelatedPosts 重新計算文章的相關文章，請在 IndexPost 之後、同一個交易中呼叫
+// 這篇文章的清單會整個重算；其他文章只更新與這篇文章之間的分數，再裁掉超過上限的部分
+func RefreshRelatedPosts(ctx context.Context, db bun.IDB, postID uint) error {
+	docs, err := loadRelatedDocs(ctx, db)
+	if err != nil {
+		return err
+	}
+	categoryParents, err := loadCategoryParents(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	var target *relatedDoc
+	for i := range docs {
+		if docs[i].PostID == postID {
+			target = &docs[i]
+			break
+		}
+	}
+
+	if err := RemoveRelatedPosts(ctx, db, postID); err != nil {
+		return err
+	}
+	if target == nil {
+		// 文章已刪除或還沒有索引
+		return nil
+	}
+
+	scores := scoreRelatedDocs(*target, docs, categoryParents)
+	if len(scores) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	rows := []entity.PostRelated{}
+	affected := []uint{}
+	for i, s := range scores {
+		if i < RelatedPostLimit {
+			rows = append(rows, entity.PostRelated{PostID: postID, RelatedPostID: s.postID, Score: s.score, UpdatedAt: now})
+		}
+		// 分數是對稱的，同時更新對方清單中這篇文章的分數
+		rows = append(rows, entity.PostRelated{PostID: s.postID, RelatedPostID: postID, Score: s.score, UpdatedAt: now})
+		affected = append(affected, s.postID)
+	}
+	if _, err := db.NewInsert().Model(&rows).Exec(ctx); err != nil {
+		return err
+	}
+
+	return trimRelatedPosts(ctx, db, affected)
+}
+
+// RemoveRelatedPosts 移除文章自己的相關文章清單，以及其他文章清單中的這篇文章
+func RemoveRelatedPosts(ctx context.Context, db bun.IDB, postID interface{}) error {
+	_, err := db.NewDelete().
+		Model((*entity.PostRelated)(nil)).
+		Where("post_id = ? OR related_post_id = ?", postID, postID).
+		Exec(ctx)
+	return err
+}
+
+// RebuildRelatedPosts 重新計算所有文章的相關文章，回傳計算的文章數
+func RebuildRelatedPosts(ctx context.Context, db bun.IDB) (int, error) {
+	docs, err := loadRelatedDocs(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	categoryParents, err := loadCategoryParents(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = db.NewDelete().
+		Model((*entity.PostRelated)(nil)).
+		Where("TRUE").
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for _, doc := range docs {
+		scores := scoreRelatedDocs(doc, docs, categoryParents)
+		if len(scores) > RelatedPostLimit {
+			scores = scores[:RelatedPostLimit]
+		}
+		if len(scores) == 0 {
+			continue
+		}
+
+		rows := make([]entity.PostRelated, 0, len(scores))
+		for _, s := range scores {
+			rows = append(rows, entity.PostRelated{PostID: doc.PostID, RelatedPostID: s.postID, Score: s.score, UpdatedAt: now})
+		}
+		if _, err := db.NewInsert().Model(&rows).Exec(ctx); err != nil {
+			return 0, err
+		}
+	}
+	return len(docs), nil
+}
+
+// 載入所有未刪除文章的索引文字（草稿也要算，發佈後才不用重算；前台查詢時再過濾可見的文章）
+func loadRelatedDocs(ctx context.Context, db bun.IDB) ([]relatedDoc, error) {
+	var docs []relatedDoc
+	err := db.NewSelect().
+		Model((*entity.PostSearchIndex)(nil)).
+		ColumnExpr("post_search_index.post_id, post_search_index.title, post_search_index.body").
+		ColumnExpr("post.category_id").
+		Join("JOIN posts AS post ON post.id = post_search_index.post_id").
+		Where("post.is_deleted = FALSE").
+		Scan(ctx, &docs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range docs {
+		docs[i].titleTerms = relatedTerms(docs[i].Title, false)
+		docs[i].bodyTerms = relatedTerms(docs[i].Body, true)
+	}
+	return docs, nil
+}
+
+func loadCategoryParents(ctx context.Context, db bun.IDB) (map[uint]uint, error) {
+	var categories []entity.Category
+	err := db.NewSelect().
+		Model(&categories).
+		Column("id", "parent").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := make(map[uint]uint, len(categories))
+	for _, category := range categories {
+		if category.Parent != nil {
+			parents[category.ID] = *category.Parent
+		}
+	}
+	return parents, nil
+}
+
+// 依分數由高到低排序，同分時依文章 ID，確保每次結果順序一致
+func scoreRelatedDocs(target relatedDoc, docs []relatedDoc, categoryParents map[uint]uint) []relatedScore {
+	scores := []relatedScore{}
+	for _, doc := range docs {
+		if doc.PostID == target.PostID {
+			continue
+		}
+
+		score := relatedCategoryWeight*categoryCloseness(target.CategoryID, doc.CategoryID, categoryParents) +
+			relatedTitleWeight*cosineSimilarity(target.titleTerms, doc.titleTerms) +
+			relatedBodyWeight*cosineSimilarity(target.bodyTerms, doc.bodyTerms)
+		// 只保留四位小數，避免浮點誤差影響排序
+		score = math.Round(score*10000) / 10000
+		if score < relatedMinScore {
+			continue
+		}
+		scores = append(scores, relatedScore{postID: doc.PostID, score: score})
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].score != scores[j].score {
+			return scores[i].score > scores[j].score
+		}
+		return scores[i].postID < scores[j].postID
+	})
+	return scores
+}
+
+// 其他文章的清單只保留分數最高的 RelatedPostLimit 筆
+func trimRelatedPosts(ctx context.Context, db bun.IDB, postIDs []uint) error {
+	ranked := db.NewSelect().
+		Model((*entity.PostRelated)(nil)).
+		Column("post_id", "related_post_id").
+		ColumnExpr("ROW_NUMBER() OVER (PARTITION BY post_id ORDER BY score DESC, related_post_id) AS row_rank").
+		Where("post_id IN (?)", bun.In(postIDs))
+
+	_, err := db.NewDelete().
+		Model((*entity.PostRelated)(nil)).
+		Where("(post_id, related_post_id) IN (?)",
+			db.NewSelect().
+				TableExpr("(?) AS ranked", ranked).
+				Column("post_id", "related_post_id").
+				Where("row_rank > ?", RelatedPostLimit),
+		).
+		Exec(ctx)
+	return err
+}
+
+// 分類在樹中的距離換成 0 ~ 1 的分數：同分類 1、上下層 1/2、同一層的兄弟分類 1/3，不在同一棵樹為 0
+func categoryCloseness(a, b uint, parents map[uint]uint) float64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+
+	// 記下 a 往上每個祖先的距離，再從 b 往上找第一個共同祖先
+	depthA := map[uint]int{}
+	for id, depth := a, 0; ; depth++ {
+		if _, seen := depthA[id]; seen {
+			break // 資料有循環時避免無限迴圈
+		}
+		depthA[id] = depth
+		parent, ok := parents[id]
+		if !ok {
+			break
+		}
+		id = parent
+	}
+
+	seen := map[uint]bool{}
+	for id, depth := b, 0; !seen[id]; depth++ {
+		seen[id] = true
+		if d, ok := depthA[id]; ok {
+			return 1 / float64(1+d+depth)
+		}
+		parent, ok := parents[id]
+		if !ok {
+			break
+		}
+		id = parent
+	}
+	return 0
+}
+
+// 用詞：英數字以單字、CJK 以 bigram 為單位（單一個字太常見，不列入）
+// 內文的詞頻取對數，避免少數重複很多次的詞主導分數
+func relatedTerms(text string, logScale bool) map[string]float64 {
+	counts := map[string]int{}
+	for _, token := range QueryTokens(text) {
+		if len([]rune(token)) < 2 {
+			continue
+		}
+		counts[token]++
+	}
+
+	terms := make(map[string]float64, len(counts))
+	for token, count := range counts {
+		if logScale {
+			terms[token] = 1 + math.Log(float64(count))
+		} else {
+			terms[token] = 1
+		}
+	}
+	return terms
+}
+
+func cosineSimilarity(a, b map[string]float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	dot := 0.0
+	for term, weight := range a {
+		dot += weight * b[term]
+	}
+	if dot == 0 {
+		return 0
+	}
+	return dot / (vectorNorm(a) * vectorNorm(b))
+}
+
+func vectorNorm(v map[string]float64) float64 {
+	sum := 0.0
+	for _, weight := range v {
+		sum += weight * weight
+	}
+	return math.Sqrt(sum)
+}
@@ -0,0 +1,108 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+)
+
+// Snippet 從內文中找出第一個命中查詢的位置，擷取前後文（最多 maxRunes 個字），
+// 並以 <mark> 標示命中的詞；回傳值已做 HTML escape，可以直接輸出
+func Snippet(body, query string, maxRunes int) string {
+	original := []rune(strings.Join(strings.Fields(body), " "))
+	normalized := make([]rune, len(original))
+	for i, r := range original {
+		normalized[i] = normalizeRune(r)
+	}
+
+	matches := findMatches(normalized, highlightTerms(query))
+
+	start := 0
+	if len(matches) > 0 {
+		start = max(0, matches[0][0]-maxRunes/3)
+	}
+	end := min(len(original), start+maxRunes)
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("...")
+	}
+	pos := start
+	for _, m := range matches {
+		if m[1] <= start || m[0] >= end {
+			continue
+		}
+		mStart, mEnd := max(m[0], start), min(m[1], end)
+		sb.WriteString(html.EscapeString(string(original[pos:mStart])))
+		sb.WriteString("<mark>")
+		sb.WriteString(html.EscapeString(string(original[mStart:mEnd])))
+		sb.WriteString("</mark>")
+		pos = mEnd
+	}
+	sb.WriteString(html.EscapeString(string(original[pos:end])))
+	if end < len(original) {
+		sb.WriteString("...")
+	}
+	return sb.String()
+}
+
+// 要標示的詞：完整的英數字單字、完整的 CJK 片段，以及 CJK bigram（完整片段沒出現時也能標示局部）
+func highlightTerms(query string) [][]rune {
+	seen := make(map[string]bool)
+	var terms [][]rune
+	add := func(term []rune) {
+		if len(term) == 0 || seen[string(term)] {
+			return
+		}
+		seen[string(term)] = true
+		terms = append(terms, term)
+	}
+
+	for _, run := range splitRuns(query) {
+		add(run.runes)
+		if run.cjk {
+			for i := 0; i+1 < len(run.runes); i++ {
+				add(run.runes[i : i+2])
+			}
+		}
+	}
+
+	// 長的詞優先，避免只標示到片段
+	sort.SliceStable(terms, func(i, j int) bool {
+		return len(terms[i]) > len(terms[j])
+	})
+	return terms
+}
+
+// 由左到右找出不重疊的命中區間 [start, end)
+func findMatches(text []rune, terms [][]rune) [][2]int {
+	var matches [][2]int
+	for i := 0; i < len(text); {
+		matched := 0
+		for _, term := range terms {
+			if hasPrefixAt(text, i, term) {
+				matched = len(term)
+				break
+			}
+		}
+		if matched == 0 {
+			i++
+			continue
+		}
+		matches = append(matches, [2]int{i, i + matched})
+		i += matched
+	}
+	return matches
+}
+
+func hasPrefixAt(text []rune, pos int, term []rune) bool {
+	if pos+len(term) > len(text) {
+		return false
+	}
+	for k, r := range term {
+		if text[pos+k] != r {
+			return false
+		}
+	}
+	return true
+}
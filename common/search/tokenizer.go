@@ -0,0 +1,102 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// Postgres 內建的全文檢索不會斷中文詞，這裡先在 Go 端把文字切成 token：
+// 英數字以單字為單位，中日韓文字以「單字 + 相鄰兩字（bigram）」為單位，再交給 to_tsvector('simple', ...)
+
+type textRun struct {
+	runes []rune
+	cjk   bool
+}
+
+// Tokenize 產生索引用的 token（CJK 同時保留單字與 bigram，單一字的查詢也能命中）
+func Tokenize(text string) []string {
+	var tokens []string
+	for _, run := range splitRuns(text) {
+		if !run.cjk {
+			tokens = append(tokens, string(run.runes))
+			continue
+		}
+		for i := range run.runes {
+			tokens = append(tokens, string(run.runes[i]))
+			if i+1 < len(run.runes) {
+				tokens = append(tokens, string(run.runes[i:i+2]))
+			}
+		}
+	}
+	return tokens
+}
+
+// QueryTokens 產生查詢用的 token（CJK 只用 bigram，只有一個字時才用單字）
+func QueryTokens(query string) []string {
+	var tokens []string
+	for _, run := range splitRuns(query) {
+		if !run.cjk || len(run.runes) == 1 {
+			tokens = append(tokens, string(run.runes))
+			continue
+		}
+		for i := 0; i+1 < len(run.runes); i++ {
+			tokens = append(tokens, string(run.runes[i:i+2]))
+		}
+	}
+	return tokens
+}
+
+// QueryText 回傳給 plainto_tsquery('simple', ?) 使用的查詢字串，所有 token 都必須命中
+func QueryText(query string) string {
+	return strings.Join(QueryTokens(query), " ")
+}
+
+// 把文字切成連續的英數字片段與 CJK 片段，其餘字元（標點、空白）視為分隔
+func splitRuns(text string) []textRun {
+	var runs []textRun
+	var current []rune
+	currentCJK := false
+
+	flush := func() {
+		if len(current) > 0 {
+			runs = append(runs, textRun{runes: current, cjk: currentCJK})
+			current = nil
+		}
+	}
+
+	for _, r := range text {
+		r = normalizeRune(r)
+		switch {
+		case isCJK(r):
+			if !currentCJK {
+				flush()
+			}
+			currentCJK = true
+			current = append(current, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if currentCJK {
+				flush()
+			}
+			currentCJK = false
+			current = append(current, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return runs
+}
+
+// 全形轉半形並轉小寫，一個字元對應一個字元（產生片段時要靠這點對齊原文位置）
+func normalizeRune(r rune) rune {
+	if folded := width.LookupRune(r).Folded(); folded != 0 {
+		r = folded
+	}
+	return unicode.ToLower(r)
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
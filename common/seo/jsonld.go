@@ -0,0 +1,103 @@
+package seo
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"blog-backend/common/entity"
+)
+
+const schemaContext = "https://schema.org"
+
+// BlogPosting schema.org 的 BlogPosting
+type BlogPosting struct {
+	Context          string    `json:"@context"`
+	Type             string    `json:"@type"`
+	Headline         string    `json:"headline"`
+	Description      string    `json:"description,omitempty"`
+	Image            []string  `json:"image,omitempty"`
+	URL              string    `json:"url"`
+	MainEntityOfPage WebPage   `json:"mainEntityOfPage"`
+	DatePublished    time.Time `json:"datePublished"`
+	DateModified     time.Time `json:"dateModified"`
+	Author           *Person   `json:"author,omitempty"`
+	ArticleSection   string    `json:"articleSection,omitempty"` // 所屬分類名稱
+	Keywords         string    `json:"keywords,omitempty"`       // 標籤，以逗號分隔
+	WordCount        int       `json:"wordCount,omitempty"`
+}
+
+type WebPage struct {
+	Type string `json:"@type"`
+	ID   string `json:"@id"`
+}
+
+type Person struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// BreadcrumbList schema.org 的 BreadcrumbList：首頁 → 各層分類 → 文章
+type BreadcrumbList struct {
+	Context         string     `json:"@context"`
+	Type            string     `json:"@type"`
+	ItemListElement []ListItem `json:"itemListElement"`
+}
+
+type ListItem struct {
+	Type     string `json:"@type"`
+	Position int    `json:"position"`
+	Name     string `json:"name"`
+	Item     string `json:"item,omitempty"` // 最後一項（目前頁面）可以省略
+}
+
+// PostJSONLD 產生文章頁的 JSON-LD（BlogPosting、BreadcrumbList），前台直接放進 <script type="application/ld+json">
+// categories 為 CategoryChain 的結果（從最上層到所屬分類）
+func PostJSONLD(post entity.Post, meta Meta, categories []entity.Category, tags []string) []interface{} {
+	posting := BlogPosting{
+		Context:          schemaContext,
+		Type:             "BlogPosting",
+		Headline:         headline(post.Title),
+		Description:      meta.Description,
+		URL:              meta.CanonicalUrl,
+		MainEntityOfPage: WebPage{Type: "WebPage", ID: meta.CanonicalUrl},
+		DatePublished:    post.CreatedAt,
+		DateModified:     post.UpdatedAt,
+		Keywords:         strings.Join(tags, ", "),
+		WordCount:        post.WordCount,
+	}
+	if meta.Image != "" {
+		posting.Image = []string{meta.Image}
+	}
+	if author := strings.TrimSpace(os.Getenv("SITE_AUTHOR")); author != "" {
+		posting.Author = &Person{Type: "Person", Name: author}
+	}
+	if len(categories) > 0 {
+		posting.ArticleSection = categories[len(categories)-1].Name
+	}
+
+	items := []ListItem{{Type: "ListItem", Position: 1, Name: SiteName(), Item: SiteBaseURL() + "/"}}
+	for _, category := range categories {
+		items = append(items, ListItem{
+			Type:     "ListItem",
+			Position: len(items) + 1,
+			Name:     category.Name,
+			Item:     CategoryURL(category.Slug),
+		})
+	}
+	items = append(items, ListItem{Type: "ListItem", Position: len(items) + 1, Name: post.Title})
+
+	return []interface{}{
+		posting,
+		BreadcrumbList{Context: schemaContext, Type: "BreadcrumbList", ItemListElement: items},
+	}
+}
+
+// Google 建議 headline 不超過 110 個字
+func headline(title string) string {
+	runes := []rune(title)
+	if len(runes) <= 110 {
+		return title
+	}
+	return string(runes[:107]) + "..."
+}
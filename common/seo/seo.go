@@ -0,0 +1,120 @@
+package seo
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+
+	"blog-backend/common/entity"
+
+	"github.com/uptrace/bun"
+)
+
+// meta description 建議長度（超過時截斷）
+const DescriptionLength = 160
+
+// Meta 前台 <head> 使用的 SEO 資料，空白的欄位已經套用預設值
+type Meta struct {
+	Title        string `json:"title"`        // og:title / twitter:title
+	Description  string `json:"description"`  // meta description / og:description
+	Image        string `json:"image"`        // og:image，沒有圖片時為空字串
+	CanonicalUrl string `json:"canonicalUrl"` // canonical 網址
+	NoIndex      bool   `json:"noIndex"`      // true 時前台應輸出 <meta name="robots" content="noindex">
+}
+
+// SiteBaseURL 前台網站網址（SITE_BASE_URL），不含結尾斜線；未設定時為空字串，產生的網址會是相對路徑
+func SiteBaseURL() string {
+	return strings.TrimRight(strings.TrimSpace(os.Getenv("SITE_BASE_URL")), "/")
+}
+
+// SiteName 網站名稱（SITE_NAME），用在麵包屑的首頁
+func SiteName() string {
+	if name := strings.TrimSpace(os.Getenv("SITE_NAME")); name != "" {
+		return name
+	}
+	return "首頁"
+}
+
+// PostURL 前台文章頁網址
+func PostURL(slug string) string {
+	return SiteBaseURL() + "/post/" + url.PathEscape(slug)
+}
+
+// CategoryURL 前台分類頁網址
+func CategoryURL(slug string) string {
+	return SiteBaseURL() + "/category/" + url.PathEscape(slug)
+}
+
+// AboutURL 前台關於我頁面網址
+func AboutURL() string {
+	return SiteBaseURL() + "/about"
+}
+
+// ResolveMeta 套用預設值：標題用 Title、描述用 Summary、圖片用封面、canonical 用前台文章網址
+func ResolveMeta(post entity.Post) Meta {
+	meta := Meta{
+		Title:        strings.TrimSpace(post.SocialTitle),
+		Description:  strings.TrimSpace(post.MetaDescription),
+		Image:        strings.TrimSpace(post.OgImageUrl),
+		CanonicalUrl: strings.TrimSpace(post.CanonicalUrl),
+		NoIndex:      post.NoIndex,
+	}
+	if meta.Title == "" {
+		meta.Title = post.Title
+	}
+	if meta.Description == "" {
+		meta.Description = post.Summary
+	}
+	meta.Description = truncateDescription(meta.Description)
+	if meta.Image == "" {
+		meta.Image = post.CoverImageUrl
+	}
+	if meta.CanonicalUrl == "" {
+		meta.CanonicalUrl = PostURL(post.Slug)
+	}
+	return meta
+}
+
+// CategoryChain 回傳從最上層到指定分類的分類（麵包屑用），找不到分類時回傳空陣列
+func CategoryChain(ctx context.Context, db bun.IDB, categoryID uint) ([]entity.Category, error) {
+	var categories []entity.Category
+	err := db.NewSelect().
+		Model(&categories).
+		Column("id", "name", "slug", "parent").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]entity.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+
+	chain := []entity.Category{}
+	visited := map[uint]bool{}
+	for id := categoryID; !visited[id]; {
+		category, ok := byID[id]
+		if !ok {
+			break
+		}
+		visited[id] = true
+		chain = append([]entity.Category{category}, chain...)
+		if category.Parent == nil {
+			break
+		}
+		id = *category.Parent
+	}
+	return chain, nil
+}
+
+// 合併空白並截斷到 DescriptionLength 個字
+func truncateDescription(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= DescriptionLength {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:DescriptionLength-3])) + "..."
+}
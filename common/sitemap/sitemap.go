@@ -0,0 +1,117 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// 每個 sitemap 檔最多 50,000 個網址（sitemaps.org 的限制），超過時改用 sitemap index
+const MaxURLs = 50000
+
+const (
+	sitemapNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	imageNS   = "http://www.google.com/schemas/sitemap-image/1.1"
+)
+
+// URL 一個頁面，LastMod 為零值時不輸出；Images 為頁面上的圖片（image sitemap）
+type URL struct {
+	Loc     string
+	LastMod time.Time
+	Images  []string
+}
+
+// IndexEntry sitemap index 中的一個 sitemap 檔
+type IndexEntry struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type urlSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	ImageNS string       `xml:"xmlns:image,attr"`
+	URLs    []urlElement `xml:"url"`
+}
+
+type urlElement struct {
+	Loc     string         `xml:"loc"`
+	LastMod string         `xml:"lastmod,omitempty"`
+	Images  []imageElement `xml:"image:image"`
+}
+
+type imageElement struct {
+	Loc string `xml:"image:loc"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name         `xml:"sitemapindex"`
+	XMLNS    string           `xml:"xmlns,attr"`
+	Sitemaps []sitemapElement `xml:"sitemap"`
+}
+
+type sitemapElement struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// RenderURLSet 輸出一個 sitemap 檔（<urlset>）
+func RenderURLSet(urls []URL) ([]byte, error) {
+	doc := urlSet{XMLNS: sitemapNS, ImageNS: imageNS, URLs: []urlElement{}}
+	for _, u := range urls {
+		element := urlElement{Loc: u.Loc, LastMod: lastMod(u.LastMod)}
+		for _, image := range u.Images {
+			element.Images = append(element.Images, imageElement{Loc: image})
+		}
+		doc.URLs = append(doc.URLs, element)
+	}
+	return marshalXML(doc)
+}
+
+// RenderIndex 輸出 sitemap index（<sitemapindex>）
+func RenderIndex(entries []IndexEntry) ([]byte, error) {
+	doc := sitemapIndex{XMLNS: sitemapNS, Sitemaps: []sitemapElement{}}
+	for _, entry := range entries {
+		doc.Sitemaps = append(doc.Sitemaps, sitemapElement{Loc: entry.Loc, LastMod: lastMod(entry.LastMod)})
+	}
+	return marshalXML(doc)
+}
+
+// Split 依每檔的上限切分網址
+func Split(urls []URL, size int) [][]URL {
+	chunks := [][]URL{}
+	for start := 0; start < len(urls); start += size {
+		end := start + size
+		if end > len(urls) {
+			end = len(urls)
+		}
+		chunks = append(chunks, urls[start:end])
+	}
+	return chunks
+}
+
+// LatestMod 一組網址中最新的 LastMod（sitemap index 的 lastmod）
+func LatestMod(urls []URL) time.Time {
+	var latest time.Time
+	for _, u := range urls {
+		if u.LastMod.After(latest) {
+			latest = u.LastMod
+		}
+	}
+	return latest
+}
+
+// W3C Datetime
+func lastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
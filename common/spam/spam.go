@@ -0,0 +1,119 @@
+package spam
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// 分數達到這個值的留言直接標記為垃圾留言，其餘進入審核佇列
+const Threshold = 0.8
+
+// Input 要評分的留言內容
+type Input struct {
+	AuthorName  string
+	AuthorEmail string
+	Content     string
+	UserAgent   string
+}
+
+// Scorer 垃圾留言評分，回傳 0 ~ 1 的分數，越高越可能是垃圾留言
+// 要改用外部服務（例如 Akismet）時實作這個介面，再用 NewPostServiceWithSpamScorer 建立前台文章服務
+type Scorer interface {
+	Score(ctx context.Context, input Input) (float64, error)
+}
+
+// regex: 內文中的網址
+var linkRegex = regexp.MustCompile(`(?i)https?://|www\.`)
+
+// Heuristic 不依賴外部服務的基本評分：網址數量、封鎖字詞（COMMENT_BLOCKED_WORDS，以逗號分隔）、全大寫與重複字元
+type Heuristic struct {
+	BlockedWords []string
+}
+
+// NewHeuristic 從環境變數讀取封鎖字詞
+func NewHeuristic() *Heuristic {
+	words := []string{}
+	for _, word := range strings.Split(os.Getenv("COMMENT_BLOCKED_WORDS"), ",") {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			words = append(words, word)
+		}
+	}
+	return &Heuristic{BlockedWords: words}
+}
+
+func (h *Heuristic) Score(ctx context.Context, input Input) (float64, error) {
+	score := 0.0
+
+	// 名稱裡放網址幾乎都是廣告
+	if linkRegex.MatchString(input.AuthorName) {
+		score += 0.5
+	}
+
+	// 一兩個網址很正常，再多就可疑
+	if links := len(linkRegex.FindAllStringIndex(input.Content, -1)); links > 2 {
+		score += 0.2 * float64(links-2)
+	}
+
+	text := strings.ToLower(input.AuthorName + " " + input.Content)
+	for _, word := range h.BlockedWords {
+		if strings.Contains(text, word) {
+			score += 0.6
+			break
+		}
+	}
+
+	if isShouting(input.Content) {
+		score += 0.2
+	}
+	if hasLongRepeat(input.Content, 10) {
+		score += 0.2
+	}
+	if strings.TrimSpace(input.UserAgent) == "" {
+		score += 0.2
+	}
+
+	if score > 1 {
+		score = 1
+	}
+	return score, nil
+}
+
+// 英文字母超過 20 個且幾乎都是大寫
+func isShouting(content string) bool {
+	letters, upper := 0, 0
+	for _, r := range content {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	return letters > 20 && float64(upper)/float64(letters) > 0.8
+}
+
+// 同一個字元連續出現 n 次以上（例如「!!!!!!!!!!」）
+func hasLongRepeat(content string, n int) bool {
+	var last rune
+	count := 0
+	for _, r := range content {
+		if unicode.IsSpace(r) {
+			last, count = 0, 0
+			continue
+		}
+		if r == last {
+			count++
+			if count >= n {
+				return true
+			}
+			continue
+		}
+		last = r
+		count = 1
+	}
+	return false
+}
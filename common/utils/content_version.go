@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"blog-backend/common/entity"
+
+	"github.com/uptrace/bun"
+)
+
+// 全站內容版本只有一筆
+const contentVersionID = 1
+
+// BumpContentVersion 內容版本加 1，與內容異動在同一個交易中呼叫（交易回滾時版本也不變）
+func BumpContentVersion(ctx context.Context, db bun.IDB) error {
+	_, err := db.NewInsert().
+		Model(&entity.ContentVersion{ID: contentVersionID, Version: 1}).
+		On("CONFLICT (id) DO UPDATE").
+		Set("version = content_version.version + 1").
+		Set("updated_at = NOW()").
+		Exec(ctx)
+	return err
+}
+
+// CurrentContentVersion 目前的內容版本，還沒有任何異動時為 0
+func CurrentContentVersion(ctx context.Context, db bun.IDB) (int64, error) {
+	var current entity.ContentVersion
+	err := db.NewSelect().
+		Model(&current).
+		Column("version").
+		Where("id = ?", contentVersionID).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return current.Version, err
+}
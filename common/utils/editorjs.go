@@ -2,7 +2,9 @@ package utils
 
 import (
 	"encoding/json"
+	"html"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -17,6 +19,24 @@ type EditorJsContent struct {
 	Blocks []EditorJsBlock `json:"blocks"`
 }
 
+// EditorJsRawBlock 保留 block 原始的 data，給需要完整內容的功能使用（例如版本比對）
+type EditorJsRawBlock struct {
+	ID   string          `json:"id,omitempty"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// ParseEditorJsBlocks 解析 Editor.js JSON，回傳所有 block（data 保持原樣）
+func ParseEditorJsBlocks(jsonContent string) ([]EditorJsRawBlock, error) {
+	var content struct {
+		Blocks []EditorJsRawBlock `json:"blocks"`
+	}
+	if err := json.Unmarshal([]byte(jsonContent), &content); err != nil {
+		return nil, err
+	}
+	return content.Blocks, nil
+}
+
 // regex: 移除 <b>、<i> 等 HTML tag
 var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
 
@@ -51,3 +71,82 @@ func ExtractSummaryFromEditorJS(jsonContent string, maxLength int) string {
 
 	return strings.TrimSpace(sb.String())
 }
+
+// 純文字擷取時略過的 data 欄位（網址、樣式等不是內容的值）
+var plainTextSkipKeys = map[string]bool{
+	"url":       true,
+	"file":      true,
+	"link":      true,
+	"source":    true,
+	"embed":     true,
+	"service":   true,
+	"style":     true,
+	"alignment": true,
+	"meta":      true,
+	"language":  true,
+	"html":      true,
+}
+
+// ExtractPlainTextFromEditorJS 取出所有 block 的文字內容（移除 HTML 標籤），以換行分隔
+func ExtractPlainTextFromEditorJS(jsonContent string) string {
+	blocks, err := ParseEditorJsBlocks(jsonContent)
+	if err != nil {
+		return ""
+	}
+
+	var parts []string
+	for _, block := range blocks {
+		var data interface{}
+		if err := json.Unmarshal(block.Data, &data); err != nil {
+			continue
+		}
+		collectPlainText(data, &parts)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// 遞迴收集 data 內的字串（支援巢狀清單、表格等結構）
+func collectPlainText(value interface{}, parts *[]string) {
+	switch v := value.(type) {
+	case string:
+		text := strings.TrimSpace(html.UnescapeString(stripHTMLTags(v)))
+		if text != "" {
+			*parts = append(*parts, text)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectPlainText(item, parts)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			if !plainTextSkipKeys[key] {
+				keys = append(keys, key)
+			}
+		}
+		// 固定順序：主要內容在前，說明文字在後
+		sort.Slice(keys, func(i, j int) bool {
+			pi, pj := plainTextKeyPriority(keys[i]), plainTextKeyPriority(keys[j])
+			if pi != pj {
+				return pi < pj
+			}
+			return keys[i] < keys[j]
+		})
+		for _, key := range keys {
+			collectPlainText(v[key], parts)
+		}
+	}
+}
+
+func plainTextKeyPriority(key string) int {
+	switch key {
+	case "title":
+		return 0
+	case "text", "content", "items", "code", "message":
+		return 1
+	case "caption":
+		return 3
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,307 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// 文章內容的輸出格式
+const (
+	ContentFormatEditorJS = "editorjs" // 原始 Editor.js JSON
+	ContentFormatHTML     = "html"     // 伺服器端轉好的 HTML
+	ContentFormatText     = "text"     // 純文字
+)
+
+// 可以直接以 iframe 嵌入的網站，其他網址只輸出連結
+var embedAllowedHosts = map[string]bool{
+	"www.youtube.com":          true,
+	"youtube.com":              true,
+	"www.youtube-nocookie.com": true,
+	"player.vimeo.com":         true,
+	"codepen.io":               true,
+	"player.twitch.tv":         true,
+	"coub.com":                 true,
+	"www.instagram.com":        true,
+	"platform.twitter.com":     true,
+	"assets.pinterest.com":     true,
+	"www.facebook.com":         true,
+	"imgur.com":                true,
+	"gfycat.com":               true,
+	"miro.com":                 true,
+}
+
+// 程式碼語言只保留安全的字元，用在 class="language-xxx"
+var codeLanguageRegex = regexp.MustCompile(`^[A-Za-z0-9_+#.-]{1,30}$`)
+
+// RenderContent 依照 format 回傳文章內容：editorjs 原樣、html 轉成 HTML、text 轉成純文字
+func RenderContent(content, format string) (string, error) {
+	switch format {
+	case ContentFormatHTML:
+		return RenderEditorJSHTML(content)
+	case ContentFormatText:
+		return ExtractPlainTextFromEditorJS(content), nil
+	default:
+		return content, nil
+	}
+}
+
+// RenderEditorJSHTML 把 Editor.js JSON 轉成語意化的 HTML，所有文字與 raw HTML 都會經過清理
+func RenderEditorJSHTML(jsonContent string) (string, error) {
+	if strings.TrimSpace(jsonContent) == "" {
+		return "", nil
+	}
+	blocks, err := ParseEditorJsBlocks(jsonContent)
+	if err != nil {
+		return "", err
+	}
+
+	// header 的 id 與目錄使用同一組 anchor
+	anchors := HeaderAnchors(blocks)
+
+	parts := []string{}
+	for i, block := range blocks {
+		if rendered := renderEditorJsBlock(block, anchors[i]); rendered != "" {
+			parts = append(parts, rendered)
+		}
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+func renderEditorJsBlock(block EditorJsRawBlock, anchor string) string {
+	var data map[string]interface{}
+	if err := json.Unmarshal(block.Data, &data); err != nil {
+		return ""
+	}
+
+	switch block.Type {
+	case "paragraph":
+		text := SanitizeInlineHTML(stringField(data, "text"))
+		if strings.TrimSpace(text) == "" {
+			return ""
+		}
+		return "<p>" + text + "</p>"
+
+	case "header":
+		level := intField(data, "level")
+		if level < 1 || level > 6 {
+			level = 2
+		}
+		return fmt.Sprintf(`<h%d id="%s">%s</h%d>`, level, html.EscapeString(anchor), SanitizeInlineHTML(stringField(data, "text")), level)
+
+	case "list", "nestedList", "nested-list":
+		items, _ := data["items"].([]interface{})
+		return renderListHTML(items, stringField(data, "style"))
+
+	case "checklist":
+		items, _ := data["items"].([]interface{})
+		var sb strings.Builder
+		sb.WriteString(`<ul class="checklist">`)
+		for _, raw := range items {
+			item, _ := raw.(map[string]interface{})
+			checked, _ := item["checked"].(bool)
+			sb.WriteString(renderChecklistItem(SanitizeInlineHTML(stringField(item, "text")), checked, ""))
+		}
+		sb.WriteString("</ul>")
+		return sb.String()
+
+	case "quote":
+		text := SanitizeInlineHTML(stringField(data, "text"))
+		caption := SanitizeInlineHTML(stringField(data, "caption"))
+		quote := "<blockquote>" + text + "</blockquote>"
+		if strings.TrimSpace(caption) == "" {
+			return quote
+		}
+		return `<figure class="quote">` + quote + "<figcaption>" + caption + "</figcaption></figure>"
+
+	case "code":
+		class := ""
+		if lang := stringField(data, "language"); codeLanguageRegex.MatchString(lang) {
+			class = ` class="language-` + lang + `"`
+		}
+		return "<pre><code" + class + ">" + html.EscapeString(stringField(data, "code")) + "</code></pre>"
+
+	case "image", "simpleImage", "simple-image":
+		return renderImageHTML(data)
+
+	case "embed":
+		return renderEmbedHTML(data)
+
+	case "table":
+		return renderTableHTML(data)
+
+	case "delimiter":
+		return "<hr>"
+
+	case "warning":
+		var sb strings.Builder
+		sb.WriteString(`<aside class="warning" role="note">`)
+		if title := SanitizeInlineHTML(stringField(data, "title")); title != "" {
+			sb.WriteString("<strong>" + title + "</strong>")
+		}
+		if message := SanitizeInlineHTML(stringField(data, "message")); message != "" {
+			sb.WriteString("<p>" + message + "</p>")
+		}
+		sb.WriteString("</aside>")
+		return sb.String()
+
+	case "raw":
+		return SanitizeRawHTML(stringField(data, "html"))
+	}
+
+	// 不認得的 block 略過
+	return ""
+}
+
+// 清單項目可能是字串（list 舊版）或 {content, meta, items}（list 2.x、nested-list）
+func renderListHTML(items []interface{}, style string) string {
+	if len(items) == 0 {
+		return ""
+	}
+
+	tag := "ul"
+	open := "<ul>"
+	switch style {
+	case "ordered":
+		tag = "ol"
+		open = "<ol>"
+	case "checklist":
+		open = `<ul class="checklist">`
+	}
+
+	var sb strings.Builder
+	sb.WriteString(open)
+	for _, raw := range items {
+		switch item := raw.(type) {
+		case string:
+			sb.WriteString("<li>" + SanitizeInlineHTML(item) + "</li>")
+		case map[string]interface{}:
+			content := SanitizeInlineHTML(stringField(item, "content"))
+			children, _ := item["items"].([]interface{})
+			nested := renderListHTML(children, style)
+
+			if style == "checklist" {
+				checked := false
+				if meta, ok := item["meta"].(map[string]interface{}); ok {
+					checked, _ = meta["checked"].(bool)
+				}
+				sb.WriteString(renderChecklistItem(content, checked, nested))
+				continue
+			}
+			sb.WriteString("<li>" + content + nested + "</li>")
+		}
+	}
+	sb.WriteString("</" + tag + ">")
+	return sb.String()
+}
+
+func renderChecklistItem(content string, checked bool, nested string) string {
+	box := `<input type="checkbox" disabled>`
+	class := ""
+	if checked {
+		box = `<input type="checkbox" disabled checked>`
+		class = ` class="checked"`
+	}
+	return "<li" + class + ">" + box + " " + content + nested + "</li>"
+}
+
+func renderImageHTML(data map[string]interface{}) string {
+	src := stringField(data, "url")
+	if file, ok := data["file"].(map[string]interface{}); ok {
+		src = stringField(file, "url")
+	}
+	if !IsSafeURL(src) {
+		return ""
+	}
+
+	classes := []string{"image"}
+	for _, option := range []string{"withBorder", "stretched", "withBackground"} {
+		if enabled, _ := data[option].(bool); enabled {
+			classes = append(classes, "image--"+option)
+		}
+	}
+
+	caption := SanitizeInlineHTML(stringField(data, "caption"))
+	alt := html.EscapeString(html.UnescapeString(stripHTMLTags(stringField(data, "caption"))))
+
+	var sb strings.Builder
+	sb.WriteString(`<figure class="` + strings.Join(classes, " ") + `">`)
+	sb.WriteString(`<img src="` + html.EscapeString(strings.TrimSpace(src)) + `" alt="` + alt + `" loading="lazy">`)
+	if strings.TrimSpace(caption) != "" {
+		sb.WriteString("<figcaption>" + caption + "</figcaption>")
+	}
+	sb.WriteString("</figure>")
+	return sb.String()
+}
+
+func renderEmbedHTML(data map[string]interface{}) string {
+	source := stringField(data, "source")
+	embed := stringField(data, "embed")
+	caption := SanitizeInlineHTML(stringField(data, "caption"))
+
+	var sb strings.Builder
+	sb.WriteString(`<figure class="embed">`)
+
+	u, err := url.Parse(embed)
+	if err == nil && u.Scheme == "https" && embedAllowedHosts[strings.ToLower(u.Host)] {
+		sb.WriteString(`<iframe src="` + html.EscapeString(embed) + `"`)
+		if width := intField(data, "width"); width > 0 {
+			sb.WriteString(fmt.Sprintf(` width="%d"`, width))
+		}
+		if height := intField(data, "height"); height > 0 {
+			sb.WriteString(fmt.Sprintf(` height="%d"`, height))
+		}
+		sb.WriteString(` frameborder="0" loading="lazy" allowfullscreen referrerpolicy="strict-origin-when-cross-origin"></iframe>`)
+	} else if IsSafeURL(source) {
+		// 不在允許清單的網站只提供連結
+		sb.WriteString(`<a href="` + html.EscapeString(source) + `" target="_blank" rel="noopener noreferrer">` + html.EscapeString(source) + `</a>`)
+	} else {
+		return ""
+	}
+
+	if strings.TrimSpace(caption) != "" {
+		sb.WriteString("<figcaption>" + caption + "</figcaption>")
+	}
+	sb.WriteString("</figure>")
+	return sb.String()
+}
+
+func renderTableHTML(data map[string]interface{}) string {
+	rows, _ := data["content"].([]interface{})
+	if len(rows) == 0 {
+		return ""
+	}
+	withHeadings, _ := data["withHeadings"].(bool)
+
+	var sb strings.Builder
+	sb.WriteString("<table>")
+	for i, raw := range rows {
+		row, _ := raw.([]interface{})
+		cellTag := "td"
+		if i == 0 && withHeadings {
+			cellTag = "th"
+			sb.WriteString("<thead>")
+		} else if i == 0 || (i == 1 && withHeadings) {
+			sb.WriteString("<tbody>")
+		}
+
+		sb.WriteString("<tr>")
+		for _, cell := range row {
+			text, _ := cell.(string)
+			sb.WriteString("<" + cellTag + ">" + SanitizeInlineHTML(text) + "</" + cellTag + ">")
+		}
+		sb.WriteString("</tr>")
+
+		if i == 0 && withHeadings {
+			sb.WriteString("</thead>")
+		}
+	}
+	if !withHeadings || len(rows) > 1 {
+		sb.WriteString("</tbody>")
+	}
+	sb.WriteString("</table>")
+	return sb.String()
+}
@@ -0,0 +1,270 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Editor.js inline HTML 轉 Markdown 用的 regex
+var (
+	inlineBoldRegex   = regexp.MustCompile(`(?is)<(?:b|strong)(?:\s[^>]*)?>(.*?)</(?:b|strong)>`)
+	inlineItalicRegex = regexp.MustCompile(`(?is)<(?:i|em)(?:\s[^>]*)?>(.*?)</(?:i|em)>`)
+	inlineStrikeRegex = regexp.MustCompile(`(?is)<(?:s|del|strike)(?:\s[^>]*)?>(.*?)</(?:s|del|strike)>`)
+	inlineCodeRegex   = regexp.MustCompile(`(?is)<code(?:\s[^>]*)?>(.*?)</code>`)
+	inlineLinkRegex   = regexp.MustCompile(`(?is)<a\s[^>]*?href\s*=\s*"([^"]*)"[^>]*>(.*?)</a>`)
+	inlineBreakRegex  = regexp.MustCompile(`(?i)<br\s*/?>`)
+)
+
+// EditorJSToMarkdown 把 Editor.js JSON 轉成 Markdown
+// 標準 block（段落、標題、清單、程式碼、引言、圖片、表格等）會轉成對應語法，無法表示的 block 以原始 HTML 或連結保留
+func EditorJSToMarkdown(jsonContent string) (string, error) {
+	blocks, err := ParseEditorJsBlocks(jsonContent)
+	if err != nil {
+		return "", err
+	}
+
+	parts := []string{}
+	for _, block := range blocks {
+		if md := editorJsBlockToMarkdown(block); md != "" {
+			parts = append(parts, md)
+		}
+	}
+	return strings.Join(parts, "\n\n") + "\n", nil
+}
+
+func editorJsBlockToMarkdown(block EditorJsRawBlock) string {
+	var data map[string]interface{}
+	if err := json.Unmarshal(block.Data, &data); err != nil {
+		return ""
+	}
+
+	switch block.Type {
+	case "paragraph":
+		return inlineHTMLToMarkdown(stringField(data, "text"))
+
+	case "header":
+		level := intField(data, "level")
+		if level < 1 || level > 6 {
+			level = 2
+		}
+		return strings.Repeat("#", level) + " " + inlineHTMLToMarkdown(stringField(data, "text"))
+
+	case "list", "nestedList", "nested-list":
+		style := stringField(data, "style")
+		items, _ := data["items"].([]interface{})
+		return strings.Join(listItemsToMarkdown(items, style, 0), "\n")
+
+	case "checklist":
+		items, _ := data["items"].([]interface{})
+		lines := []string{}
+		for _, raw := range items {
+			item, _ := raw.(map[string]interface{})
+			mark := " "
+			if checked, _ := item["checked"].(bool); checked {
+				mark = "x"
+			}
+			lines = append(lines, fmt.Sprintf("- [%s] %s", mark, inlineHTMLToMarkdown(stringField(item, "text"))))
+		}
+		return strings.Join(lines, "\n")
+
+	case "code":
+		code := stringField(data, "code")
+		fence := "```"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		return fence + stringField(data, "language") + "\n" + code + "\n" + fence
+
+	case "quote":
+		text := inlineHTMLToMarkdown(stringField(data, "text"))
+		if caption := inlineHTMLToMarkdown(stringField(data, "caption")); caption != "" {
+			text += "\n\n— " + caption
+		}
+		return quoteMarkdown(text)
+
+	case "warning":
+		text := "**" + inlineHTMLToMarkdown(stringField(data, "title")) + "**"
+		if message := inlineHTMLToMarkdown(stringField(data, "message")); message != "" {
+			text += "\n\n" + message
+		}
+		return quoteMarkdown(text)
+
+	case "delimiter":
+		return "---"
+
+	case "image", "simpleImage", "simple-image":
+		url := stringField(data, "url")
+		if file, ok := data["file"].(map[string]interface{}); ok {
+			url = stringField(file, "url")
+		}
+		if url == "" {
+			return ""
+		}
+		return fmt.Sprintf("![%s](%s)", inlineHTMLToPlain(stringField(data, "caption")), url)
+
+	case "table":
+		return tableToMarkdown(data)
+
+	case "embed":
+		source := stringField(data, "source")
+		if source == "" {
+			return ""
+		}
+		label := inlineHTMLToPlain(stringField(data, "caption"))
+		if label == "" {
+			label = source
+		}
+		return fmt.Sprintf("[%s](%s)", label, source)
+
+	case "linkTool":
+		link := stringField(data, "link")
+		if link == "" {
+			return ""
+		}
+		label := link
+		if meta, ok := data["meta"].(map[string]interface{}); ok && stringField(meta, "title") != "" {
+			label = stringField(meta, "title")
+		}
+		return fmt.Sprintf("[%s](%s)", label, link)
+
+	case "attaches":
+		file, _ := data["file"].(map[string]interface{})
+		url := stringField(file, "url")
+		if url == "" {
+			return ""
+		}
+		label := stringField(data, "title")
+		if label == "" {
+			label = stringField(file, "name")
+		}
+		if label == "" {
+			label = url
+		}
+		return fmt.Sprintf("[%s](%s)", label, url)
+
+	case "raw":
+		return strings.TrimSpace(stringField(data, "html"))
+	}
+
+	// 不認得的 block：盡量保留文字內容
+	return inlineHTMLToMarkdown(stringField(data, "text"))
+}
+
+// 清單項目可能是字串（list 舊版）或 {content, items}（list 2.x、nested-list）
+func listItemsToMarkdown(items []interface{}, style string, depth int) []string {
+	lines := []string{}
+	indent := strings.Repeat("  ", depth)
+	if style == "ordered" {
+		indent = strings.Repeat("   ", depth)
+	}
+	for i, raw := range items {
+		prefix := "- "
+		if style == "ordered" {
+			prefix = fmt.Sprintf("%d. ", i+1)
+		}
+
+		switch item := raw.(type) {
+		case string:
+			lines = append(lines, indent+prefix+inlineHTMLToMarkdown(item))
+		case map[string]interface{}:
+			if style == "checklist" {
+				prefix = "- [ ] "
+				if meta, ok := item["meta"].(map[string]interface{}); ok {
+					if checked, _ := meta["checked"].(bool); checked {
+						prefix = "- [x] "
+					}
+				}
+			}
+			lines = append(lines, indent+prefix+inlineHTMLToMarkdown(stringField(item, "content")))
+			if children, ok := item["items"].([]interface{}); ok && len(children) > 0 {
+				lines = append(lines, listItemsToMarkdown(children, style, depth+1)...)
+			}
+		}
+	}
+	return lines
+}
+
+func tableToMarkdown(data map[string]interface{}) string {
+	rows, _ := data["content"].([]interface{})
+	if len(rows) == 0 {
+		return ""
+	}
+
+	cells := [][]string{}
+	width := 0
+	for _, raw := range rows {
+		row, _ := raw.([]interface{})
+		line := []string{}
+		for _, cell := range row {
+			text, _ := cell.(string)
+			text = strings.ReplaceAll(inlineHTMLToMarkdown(text), "\n", " ")
+			line = append(line, strings.ReplaceAll(text, "|", `\|`))
+		}
+		width = max(width, len(line))
+		cells = append(cells, line)
+	}
+
+	// Markdown 表格一定要有標題列，沒有標題時補一列空白
+	withHeadings, _ := data["withHeadings"].(bool)
+	if !withHeadings {
+		cells = append([][]string{make([]string, width)}, cells...)
+	}
+
+	lines := []string{}
+	for i, row := range cells {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", width))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func quoteMarkdown(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// 把 Editor.js 的 inline HTML 轉成 Markdown 語法，其他 tag 只保留文字
+func inlineHTMLToMarkdown(text string) string {
+	text = inlineCodeRegex.ReplaceAllString(text, "`$1`")
+	text = inlineLinkRegex.ReplaceAllString(text, "[$2]($1)")
+	text = inlineBoldRegex.ReplaceAllString(text, "**$1**")
+	text = inlineItalicRegex.ReplaceAllString(text, "*$1*")
+	text = inlineStrikeRegex.ReplaceAllString(text, "~~$1~~")
+	text = inlineBreakRegex.ReplaceAllString(text, "  \n")
+	text = stripHTMLTags(text)
+	return strings.TrimSpace(html.UnescapeString(strings.ReplaceAll(text, "&nbsp;", " ")))
+}
+
+// 圖片說明等只能放純文字的地方
+func inlineHTMLToPlain(text string) string {
+	text = inlineBreakRegex.ReplaceAllString(text, " ")
+	return strings.TrimSpace(html.UnescapeString(stripHTMLTags(text)))
+}
+
+func stringField(data map[string]interface{}, key string) string {
+	value, _ := data[key].(string)
+	return value
+}
+
+func intField(data map[string]interface{}, key string) int {
+	switch value := data[key].(type) {
+	case float64:
+		return int(value)
+	case string:
+		var n int
+		fmt.Sscanf(value, "%d", &n)
+		return n
+	}
+	return 0
+}
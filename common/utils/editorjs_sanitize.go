@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"html"
+)
+
+// SanitizeEditorJS 清理 Editor.js 內容中所有會被前台當成 HTML 輸出的文字欄位
+// 文字欄位只保留 b、i、a、code、mark、u、br（storedInlineHTMLPolicy），raw block 使用 SanitizeRawHTML
+// 回傳清理後的 JSON 與是否有變動；沒有變動時回傳原本的字串，其他欄位與格式保持不變
+func SanitizeEditorJS(jsonContent string) (string, bool, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(jsonContent), &doc); err != nil {
+		return jsonContent, false, err
+	}
+	if len(doc["blocks"]) == 0 {
+		return jsonContent, false, nil
+	}
+
+	var blocks []map[string]json.RawMessage
+	if err := json.Unmarshal(doc["blocks"], &blocks); err != nil {
+		return jsonContent, false, err
+	}
+
+	changed := false
+	for _, block := range blocks {
+		var blockType string
+		if err := json.Unmarshal(block["type"], &blockType); err != nil {
+			continue
+		}
+
+		// UseNumber：數字欄位（例如 header 的 level）原樣保留
+		decoder := json.NewDecoder(bytes.NewReader(block["data"]))
+		decoder.UseNumber()
+		var data map[string]interface{}
+		if err := decoder.Decode(&data); err != nil || data == nil {
+			continue
+		}
+
+		if !sanitizeEditorJSBlockData(blockType, data) {
+			continue
+		}
+		raw, err := marshalJSONNoEscape(data)
+		if err != nil {
+			return jsonContent, false, err
+		}
+		block["data"] = raw
+		changed = true
+	}
+	if !changed {
+		return jsonContent, false, nil
+	}
+
+	raw, err := marshalJSONNoEscape(blocks)
+	if err != nil {
+		return jsonContent, false, err
+	}
+	doc["blocks"] = raw
+
+	out, err := marshalJSONNoEscape(doc)
+	if err != nil {
+		return jsonContent, false, err
+	}
+	return string(out), true, nil
+}
+
+// 清理一個 block 的 data，回傳是否有變動（欄位與 RenderEditorJSHTML 輸出 HTML 的欄位相同）
+func sanitizeEditorJSBlockData(blockType string, data map[string]interface{}) bool {
+	switch blockType {
+	case "paragraph", "header":
+		return sanitizeInlineField(data, "text")
+
+	case "list", "nestedList", "nested-list":
+		items, _ := data["items"].([]interface{})
+		return sanitizeListItems(items)
+
+	case "checklist":
+		changed := false
+		items, _ := data["items"].([]interface{})
+		for _, raw := range items {
+			if item, ok := raw.(map[string]interface{}); ok && sanitizeInlineField(item, "text") {
+				changed = true
+			}
+		}
+		return changed
+
+	case "quote":
+		text := sanitizeInlineField(data, "text")
+		caption := sanitizeInlineField(data, "caption")
+		return text || caption
+
+	case "warning":
+		title := sanitizeInlineField(data, "title")
+		message := sanitizeInlineField(data, "message")
+		return title || message
+
+	case "table":
+		changed := false
+		rows, _ := data["content"].([]interface{})
+		for _, raw := range rows {
+			row, _ := raw.([]interface{})
+			for i, cell := range row {
+				text, ok := cell.(string)
+				if !ok {
+					continue
+				}
+				if clean := sanitizeStoredInlineHTML(text); !sameHTML(text, clean) {
+					row[i] = clean
+					changed = true
+				}
+			}
+		}
+		return changed
+
+	case "image", "simpleImage", "simple-image", "embed":
+		return sanitizeInlineField(data, "caption")
+
+	case "raw":
+		text, ok := data["html"].(string)
+		if !ok {
+			return false
+		}
+		if clean := SanitizeRawHTML(text); !sameHTML(text, clean) {
+			data["html"] = clean
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// 清單項目可能是字串（list 舊版）或 {content, meta, items}（list 2.x、nested-list）
+func sanitizeListItems(items []interface{}) bool {
+	changed := false
+	for i, raw := range items {
+		switch item := raw.(type) {
+		case string:
+			if clean := sanitizeStoredInlineHTML(item); !sameHTML(item, clean) {
+				items[i] = clean
+				changed = true
+			}
+		case map[string]interface{}:
+			if sanitizeInlineField(item, "content") {
+				changed = true
+			}
+			children, _ := item["items"].([]interface{})
+			if sanitizeListItems(children) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func sanitizeInlineField(data map[string]interface{}, key string) bool {
+	text, ok := data[key].(string)
+	if !ok {
+		return false
+	}
+	clean := sanitizeStoredInlineHTML(text)
+	if sameHTML(text, clean) {
+		return false
+	}
+	data[key] = clean
+	return true
+}
+
+// 清理時文字會重新做 HTML 跳脫（例如 &nbsp; 變成實際的字元），只有跳脫方式不同時視為沒有變動，保留原本的寫法
+func sameHTML(original, sanitized string) bool {
+	return original == sanitized || html.UnescapeString(original) == html.UnescapeString(sanitized)
+}
+
+// 與 MarshalEditorJS 相同，不跳脫 <、>、&
+func marshalJSONNoEscape(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,454 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Editor.js 內容的預設大小限制
+const (
+	defaultEditorJSMaxBytes      = 2 * 1024 * 1024 // 整份 JSON 2MB
+	defaultEditorJSMaxBlocks     = 2000
+	defaultEditorJSMaxFieldBytes = 100 * 1024 // 單一文字欄位 100KB
+	editorJSMaxListDepth         = 10
+)
+
+// EditorJSIssue 內容驗證失敗的位置（JSON pointer，例如 /blocks/3/data/file/url）與原因
+type EditorJSIssue struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// EditorJSRules 驗證規則，DefaultEditorJSRules 會從環境變數讀取設定
+type EditorJSRules struct {
+	AllowedBlocks map[string]bool // 允許的 block type
+	MaxBytes      int             // 整份 JSON 的大小上限
+	MaxBlocks     int             // block 數量上限
+	MaxFieldBytes int             // 單一文字欄位的大小上限
+	ImageBaseURL  string          // 圖片網址必須以此開頭（R2_PUBLIC_BASE_URL），空字串時只檢查 http(s)
+}
+
+// 欄位型別
+type jsonKind int
+
+const (
+	jsonString jsonKind = iota
+	jsonNumber
+	jsonBool
+	jsonArray
+	jsonObject
+)
+
+func (k jsonKind) String() string {
+	return [...]string{"字串", "數字", "布林值", "陣列", "物件"}[k]
+}
+
+type editorJSField struct {
+	kind     jsonKind
+	required bool
+}
+
+// editorJSBlockSchema 一種 block 的 data 欄位與額外檢查（沒有列出的欄位不檢查，保留給套件的其他設定）
+type editorJSBlockSchema struct {
+	fields map[string]editorJSField
+	check  func(v *editorJSValidator, data map[string]interface{}, path string)
+}
+
+func requiredField(kind jsonKind) editorJSField { return editorJSField{kind: kind, required: true} }
+func optionalField(kind jsonKind) editorJSField { return editorJSField{kind: kind} }
+
+// 支援的 block 與 data 格式
+var editorJSBlockSchemas = map[string]editorJSBlockSchema{
+	"paragraph": {fields: map[string]editorJSField{"text": requiredField(jsonString)}},
+	"header": {
+		fields: map[string]editorJSField{"text": requiredField(jsonString), "level": requiredField(jsonNumber)},
+		check: func(v *editorJSValidator, data map[string]interface{}, path string) {
+			if level, ok := data["level"].(float64); ok && (level < 1 || level > 6 || level != float64(int(level))) {
+				v.add(path+"/level", "標題層級必須是 1 到 6 的整數")
+			}
+		},
+	},
+	"list":        {fields: listFields(), check: checkListBlock},
+	"nestedList":  {fields: listFields(), check: checkListBlock},
+	"nested-list": {fields: listFields(), check: checkListBlock},
+	"checklist": {
+		fields: map[string]editorJSField{"items": requiredField(jsonArray)},
+		check: func(v *editorJSValidator, data map[string]interface{}, path string) {
+			items, _ := data["items"].([]interface{})
+			for i, raw := range items {
+				itemPath := fmt.Sprintf("%s/items/%d", path, i)
+				item, ok := raw.(map[string]interface{})
+				if !ok {
+					v.add(itemPath, "清單項目必須是物件")
+					continue
+				}
+				v.checkFields(item, itemPath, map[string]editorJSField{"text": requiredField(jsonString), "checked": optionalField(jsonBool)})
+			}
+		},
+	},
+	"quote": {fields: map[string]editorJSField{"text": requiredField(jsonString), "caption": optionalField(jsonString), "alignment": optionalField(jsonString)}},
+	"code":  {fields: map[string]editorJSField{"code": requiredField(jsonString), "language": optionalField(jsonString)}},
+	"image": {
+		fields: map[string]editorJSField{
+			"file":           requiredField(jsonObject),
+			"caption":        optionalField(jsonString),
+			"withBorder":     optionalField(jsonBool),
+			"stretched":      optionalField(jsonBool),
+			"withBackground": optionalField(jsonBool),
+		},
+		check: func(v *editorJSValidator, data map[string]interface{}, path string) {
+			file, _ := data["file"].(map[string]interface{})
+			if file == nil {
+				return
+			}
+			if v.checkFields(file, path+"/file", map[string]editorJSField{"url": requiredField(jsonString)}) {
+				v.checkImageURL(file["url"].(string), path+"/file/url")
+			}
+		},
+	},
+	"simpleImage":  {fields: simpleImageFields(), check: checkSimpleImageBlock},
+	"simple-image": {fields: simpleImageFields(), check: checkSimpleImageBlock},
+	"embed": {
+		fields: map[string]editorJSField{
+			"service": requiredField(jsonString),
+			"source":  requiredField(jsonString),
+			"embed":   requiredField(jsonString),
+			"width":   optionalField(jsonNumber),
+			"height":  optionalField(jsonNumber),
+			"caption": optionalField(jsonString),
+		},
+		check: func(v *editorJSValidator, data map[string]interface{}, path string) {
+			for _, key := range []string{"source", "embed"} {
+				if value, ok := data[key].(string); ok && !isHTTPURL(value) {
+					v.add(path+"/"+key, "網址必須是 http 或 https")
+				}
+			}
+		},
+	},
+	"table": {
+		fields: map[string]editorJSField{"content": requiredField(jsonArray), "withHeadings": optionalField(jsonBool)},
+		check: func(v *editorJSValidator, data map[string]interface{}, path string) {
+			rows, _ := data["content"].([]interface{})
+			for i, raw := range rows {
+				rowPath := fmt.Sprintf("%s/content/%d", path, i)
+				row, ok := raw.([]interface{})
+				if !ok {
+					v.add(rowPath, "表格的每一列必須是陣列")
+					continue
+				}
+				for j, cell := range row {
+					cellPath := fmt.Sprintf("%s/%d", rowPath, j)
+					if text, ok := cell.(string); !ok {
+						v.add(cellPath, "表格儲存格必須是字串")
+					} else {
+						v.checkStringSize(text, cellPath)
+					}
+				}
+			}
+		},
+	},
+	"delimiter": {fields: map[string]editorJSField{}},
+	"warning":   {fields: map[string]editorJSField{"title": optionalField(jsonString), "message": optionalField(jsonString)}},
+	"raw":       {fields: map[string]editorJSField{"html": requiredField(jsonString)}},
+	"linkTool": {
+		fields: map[string]editorJSField{"link": requiredField(jsonString), "meta": optionalField(jsonObject)},
+		check: func(v *editorJSValidator, data map[string]interface{}, path string) {
+			if link, ok := data["link"].(string); ok && !isHTTPURL(link) {
+				v.add(path+"/link", "網址必須是 http 或 https")
+			}
+		},
+	},
+	"attaches": {
+		fields: map[string]editorJSField{"file": requiredField(jsonObject), "title": optionalField(jsonString)},
+		check: func(v *editorJSValidator, data map[string]interface{}, path string) {
+			file, _ := data["file"].(map[string]interface{})
+			if file == nil {
+				return
+			}
+			if v.checkFields(file, path+"/file", map[string]editorJSField{"url": requiredField(jsonString)}) && !isHTTPURL(file["url"].(string)) {
+				v.add(path+"/file/url", "網址必須是 http 或 https")
+			}
+		},
+	},
+}
+
+func listFields() map[string]editorJSField {
+	return map[string]editorJSField{"style": optionalField(jsonString), "items": requiredField(jsonArray)}
+}
+
+func simpleImageFields() map[string]editorJSField {
+	return map[string]editorJSField{"url": requiredField(jsonString), "caption": optionalField(jsonString)}
+}
+
+func checkSimpleImageBlock(v *editorJSValidator, data map[string]interface{}, path string) {
+	if value, ok := data["url"].(string); ok {
+		v.checkImageURL(value, path+"/url")
+	}
+}
+
+func checkListBlock(v *editorJSValidator, data map[string]interface{}, path string) {
+	if style, ok := data["style"].(string); ok {
+		switch style {
+		case "ordered", "unordered", "checklist":
+		default:
+			v.add(path+"/style", "清單樣式必須是 ordered、unordered 或 checklist")
+		}
+	}
+	items, _ := data["items"].([]interface{})
+	v.checkListItems(items, path+"/items", 1)
+}
+
+// DefaultEditorJSRules 預設規則：支援所有內建的 block，可以用環境變數調整
+//
+//	EDITORJS_ALLOWED_BLOCKS     允許的 block type，以逗號分隔（只能從支援的 block 中挑選）
+//	EDITORJS_MAX_BYTES          整份 JSON 的大小上限（bytes，預設 2MB）
+//	EDITORJS_MAX_BLOCKS         block 數量上限（預設 2000）
+//	EDITORJS_MAX_FIELD_BYTES    單一文字欄位的大小上限（bytes，預設 100KB）
+//	R2_PUBLIC_BASE_URL          圖片網址必須放在 R2
+func DefaultEditorJSRules() EditorJSRules {
+	rules := EditorJSRules{
+		AllowedBlocks: map[string]bool{},
+		MaxBytes:      envInt("EDITORJS_MAX_BYTES", defaultEditorJSMaxBytes),
+		MaxBlocks:     envInt("EDITORJS_MAX_BLOCKS", defaultEditorJSMaxBlocks),
+		MaxFieldBytes: envInt("EDITORJS_MAX_FIELD_BYTES", defaultEditorJSMaxFieldBytes),
+		ImageBaseURL:  strings.TrimRight(strings.TrimSpace(os.Getenv("R2_PUBLIC_BASE_URL")), "/"),
+	}
+
+	allowed := strings.TrimSpace(os.Getenv("EDITORJS_ALLOWED_BLOCKS"))
+	if allowed == "" {
+		for blockType := range editorJSBlockSchemas {
+			rules.AllowedBlocks[blockType] = true
+		}
+		return rules
+	}
+	for _, blockType := range strings.Split(allowed, ",") {
+		blockType = strings.TrimSpace(blockType)
+		if _, ok := editorJSBlockSchemas[blockType]; ok {
+			rules.AllowedBlocks[blockType] = true
+		}
+	}
+	return rules
+}
+
+// ValidateEditorJS 依規則檢查 Editor.js JSON，回傳所有問題（沒有問題時為空）
+func ValidateEditorJS(jsonContent string, rules EditorJSRules) []EditorJSIssue {
+	v := &editorJSValidator{rules: rules}
+
+	if rules.MaxBytes > 0 && len(jsonContent) > rules.MaxBytes {
+		v.add("", fmt.Sprintf("內容大小 %d bytes 超過上限 %d bytes", len(jsonContent), rules.MaxBytes))
+		return v.issues
+	}
+
+	var doc map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(jsonContent)))
+	if err := decoder.Decode(&doc); err != nil || doc == nil {
+		v.add("", "內容不是有效的 Editor.js JSON 物件")
+		return v.issues
+	}
+	if decoder.More() {
+		v.add("", "JSON 物件後面還有其他內容")
+		return v.issues
+	}
+
+	if value, ok := doc["time"]; ok {
+		if _, isNumber := value.(float64); !isNumber {
+			v.add("/time", "time 必須是數字")
+		}
+	}
+	if value, ok := doc["version"]; ok {
+		if _, isString := value.(string); !isString {
+			v.add("/version", "version 必須是字串")
+		}
+	}
+
+	blocks, ok := doc["blocks"].([]interface{})
+	if !ok {
+		v.add("/blocks", "缺少 blocks 陣列")
+		return v.issues
+	}
+	if rules.MaxBlocks > 0 && len(blocks) > rules.MaxBlocks {
+		v.add("/blocks", fmt.Sprintf("block 數量 %d 超過上限 %d", len(blocks), rules.MaxBlocks))
+		return v.issues
+	}
+
+	for i, raw := range blocks {
+		v.checkBlock(raw, fmt.Sprintf("/blocks/%d", i))
+	}
+	return v.issues
+}
+
+type editorJSValidator struct {
+	rules  EditorJSRules
+	issues []EditorJSIssue
+}
+
+func (v *editorJSValidator) add(path, message string) {
+	v.issues = append(v.issues, EditorJSIssue{Path: path, Message: message})
+}
+
+func (v *editorJSValidator) checkBlock(raw interface{}, path string) {
+	block, ok := raw.(map[string]interface{})
+	if !ok {
+		v.add(path, "block 必須是物件")
+		return
+	}
+
+	if id, exists := block["id"]; exists {
+		if _, isString := id.(string); !isString {
+			v.add(path+"/id", "id 必須是字串")
+		}
+	}
+
+	blockType, _ := block["type"].(string)
+	if blockType == "" {
+		v.add(path+"/type", "缺少 block type")
+		return
+	}
+	schema, known := editorJSBlockSchemas[blockType]
+	if !known || !v.rules.AllowedBlocks[blockType] {
+		v.add(path+"/type", fmt.Sprintf("不支援的 block type：%s", blockType))
+		return
+	}
+
+	data, ok := block["data"].(map[string]interface{})
+	if !ok {
+		v.add(path+"/data", "data 必須是物件")
+		return
+	}
+	dataPath := path + "/data"
+	if !v.checkFields(data, dataPath, schema.fields) {
+		return
+	}
+	if schema.check != nil {
+		schema.check(v, data, dataPath)
+	}
+}
+
+// 檢查欄位型別與字串大小，必填欄位都存在且型別正確時回傳 true
+func (v *editorJSValidator) checkFields(data map[string]interface{}, path string, fields map[string]editorJSField) bool {
+	valid := true
+
+	// 依欄位名稱排序，讓錯誤順序固定
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field := fields[name]
+		fieldPath := path + "/" + jsonPointerEscape(name)
+		value, exists := data[name]
+		if !exists || value == nil {
+			if field.required {
+				v.add(fieldPath, "缺少必填欄位")
+				valid = false
+			}
+			continue
+		}
+		if !isKind(value, field.kind) {
+			v.add(fieldPath, fmt.Sprintf("必須是%s", field.kind))
+			valid = false
+			continue
+		}
+		if text, ok := value.(string); ok {
+			v.checkStringSize(text, fieldPath)
+		}
+	}
+	return valid
+}
+
+func (v *editorJSValidator) checkStringSize(text, path string) {
+	if v.rules.MaxFieldBytes > 0 && len(text) > v.rules.MaxFieldBytes {
+		v.add(path, fmt.Sprintf("內容大小 %d bytes 超過上限 %d bytes", len(text), v.rules.MaxFieldBytes))
+	} else if !utf8.ValidString(text) {
+		v.add(path, "包含無效的 UTF-8 字元")
+	}
+}
+
+// 清單項目可能是字串（list 舊版）或 {content, meta, items}（list 2.x、nested-list）
+func (v *editorJSValidator) checkListItems(items []interface{}, path string, depth int) {
+	if depth > editorJSMaxListDepth {
+		v.add(path, fmt.Sprintf("巢狀清單超過 %d 層", editorJSMaxListDepth))
+		return
+	}
+	for i, raw := range items {
+		itemPath := fmt.Sprintf("%s/%d", path, i)
+		switch item := raw.(type) {
+		case string:
+			v.checkStringSize(item, itemPath)
+		case map[string]interface{}:
+			fields := map[string]editorJSField{
+				"content": requiredField(jsonString),
+				"items":   optionalField(jsonArray),
+				"meta":    optionalField(jsonObject),
+			}
+			if !v.checkFields(item, itemPath, fields) {
+				continue
+			}
+			if children, ok := item["items"].([]interface{}); ok {
+				v.checkListItems(children, itemPath+"/items", depth+1)
+			}
+		default:
+			v.add(itemPath, "清單項目必須是字串或物件")
+		}
+	}
+}
+
+// 圖片必須是 R2 上的網址（也擋掉 data: 開頭的 base64 圖片）
+func (v *editorJSValidator) checkImageURL(value, path string) {
+	value = strings.TrimSpace(value)
+	if !isHTTPURL(value) {
+		v.add(path, "圖片網址必須是 http 或 https")
+		return
+	}
+	if base := v.rules.ImageBaseURL; base != "" && value != base && !strings.HasPrefix(value, base+"/") {
+		v.add(path, fmt.Sprintf("圖片必須上傳到 %s", base))
+	}
+}
+
+func isKind(value interface{}, kind jsonKind) bool {
+	switch kind {
+	case jsonString:
+		_, ok := value.(string)
+		return ok
+	case jsonNumber:
+		_, ok := value.(float64)
+		return ok
+	case jsonBool:
+		_, ok := value.(bool)
+		return ok
+	case jsonArray:
+		_, ok := value.([]interface{})
+		return ok
+	case jsonObject:
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(strings.TrimSpace(value))
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// JSON pointer 的 key 需要把 ~ 與 / 轉成 ~0、~1
+func jsonPointerEscape(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+func envInt(key string, fallback int) int {
+	n, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	return n
+}
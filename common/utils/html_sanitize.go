@@ -0,0 +1,282 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// 每個允許的 tag 可以保留的屬性
+type htmlPolicy map[string]map[string]bool
+
+func htmlAttrs(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, name := range names {
+		m[name] = true
+	}
+	return m
+}
+
+// Editor.js 文字欄位中的 inline HTML（粗體、斜體、連結、inline code、標記等）
+var inlineHTMLPolicy = htmlPolicy{
+	"b":      htmlAttrs(),
+	"strong": htmlAttrs(),
+	"i":      htmlAttrs(),
+	"em":     htmlAttrs(),
+	"u":      htmlAttrs("class"),
+	"s":      htmlAttrs(),
+	"del":    htmlAttrs(),
+	"mark":   htmlAttrs("class"),
+	"code":   htmlAttrs("class"),
+	"a":      htmlAttrs("href", "target", "rel"),
+	"br":     htmlAttrs(),
+	"sub":    htmlAttrs(),
+	"sup":    htmlAttrs(),
+	"span":   htmlAttrs("class"),
+}
+
+// 寫入 Editor.js 內容時文字欄位允許的 inline HTML，比前台輸出時（inlineHTMLPolicy）更嚴格
+var storedInlineHTMLPolicy = htmlPolicy{
+	"b":    htmlAttrs(),
+	"i":    htmlAttrs(),
+	"a":    htmlAttrs("href"),
+	"code": htmlAttrs(),
+	"mark": htmlAttrs(),
+	"u":    htmlAttrs(),
+	"br":   htmlAttrs(),
+}
+
+// raw block 允許的 HTML：inline 之外再加上一般排版用的 block tag
+var rawHTMLPolicy = func() htmlPolicy {
+	policy := htmlPolicy{
+		"p":          htmlAttrs("class"),
+		"div":        htmlAttrs("class"),
+		"h1":         htmlAttrs("id"),
+		"h2":         htmlAttrs("id"),
+		"h3":         htmlAttrs("id"),
+		"h4":         htmlAttrs("id"),
+		"h5":         htmlAttrs("id"),
+		"h6":         htmlAttrs("id"),
+		"ul":         htmlAttrs("class"),
+		"ol":         htmlAttrs("class", "start"),
+		"li":         htmlAttrs("class"),
+		"blockquote": htmlAttrs("class"),
+		"pre":        htmlAttrs("class"),
+		"hr":         htmlAttrs(),
+		"table":      htmlAttrs("class"),
+		"thead":      htmlAttrs(),
+		"tbody":      htmlAttrs(),
+		"tr":         htmlAttrs(),
+		"th":         htmlAttrs("colspan", "rowspan"),
+		"td":         htmlAttrs("colspan", "rowspan"),
+		"img":        htmlAttrs("src", "alt", "title", "width", "height"),
+		"figure":     htmlAttrs("class"),
+		"figcaption": htmlAttrs(),
+		"small":      htmlAttrs(),
+		"abbr":       htmlAttrs("title"),
+		"cite":       htmlAttrs(),
+		"kbd":        htmlAttrs(),
+		"dl":         htmlAttrs(),
+		"dt":         htmlAttrs(),
+		"dd":         htmlAttrs(),
+	}
+	for tag, allowed := range inlineHTMLPolicy {
+		policy[tag] = allowed
+	}
+	return policy
+}()
+
+// 連同內容一起移除的 tag
+var htmlDropWithContent = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"object":   true,
+	"embed":    true,
+	"noscript": true,
+	"template": true,
+	"textarea": true,
+	"select":   true,
+	"title":    true,
+	"svg":      true,
+	"math":     true,
+}
+
+var htmlVoidTags = map[string]bool{
+	"br":  true,
+	"hr":  true,
+	"img": true,
+	"wbr": true,
+}
+
+// class 只允許一般的 class 名稱
+var htmlClassRegex = regexp.MustCompile(`^[A-Za-z0-9_\- ]*$`)
+
+// 數字屬性（width、colspan 等）
+var htmlNumberRegex = regexp.MustCompile(`^\d{1,4}$`)
+
+// SanitizeInlineHTML 只保留 Editor.js 文字欄位會用到的 inline tag，其餘 tag 移除但保留文字
+func SanitizeInlineHTML(input string) string {
+	return sanitizeHTML(input, inlineHTMLPolicy)
+}
+
+// 寫入時清理文字欄位，只保留 b、i、a、code、mark、u、br
+func sanitizeStoredInlineHTML(input string) string {
+	return sanitizeHTML(input, storedInlineHTMLPolicy)
+}
+
+// SanitizeRawHTML 清理 raw block 的 HTML，保留排版用的 tag，移除 script、事件屬性與不安全的網址
+func SanitizeRawHTML(input string) string {
+	return sanitizeHTML(input, rawHTMLPolicy)
+}
+
+// IsSafeURL 只允許 http、https、mailto、tel 與相對路徑
+func IsSafeURL(raw string) bool {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return false
+	}
+	// 瀏覽器解析網址時會忽略控制字元與空白，先移除再判斷 scheme（避免 java\tscript: 之類的寫法）
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value)
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto", "tel":
+		return true
+	}
+	return false
+}
+
+func sanitizeHTML(input string, policy htmlPolicy) string {
+	z := html.NewTokenizer(strings.NewReader(input))
+	var sb strings.Builder
+	var open []string
+	skipDepth := 0
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			// 補上沒有關閉的 tag
+			for i := len(open) - 1; i >= 0; i-- {
+				sb.WriteString("</" + open[i] + ">")
+			}
+			return sb.String()
+
+		case html.TextToken:
+			if skipDepth == 0 {
+				sb.WriteString(html.EscapeString(string(z.Text())))
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			if htmlDropWithContent[tok.Data] {
+				if tt == html.StartTagToken {
+					skipDepth++
+				}
+				continue
+			}
+			if skipDepth > 0 {
+				continue
+			}
+			allowed, ok := policy[tok.Data]
+			if !ok {
+				continue
+			}
+			tag, ok := renderSanitizedTag(tok, allowed)
+			if !ok {
+				continue
+			}
+			sb.WriteString(tag)
+			if tt == html.StartTagToken && !htmlVoidTags[tok.Data] {
+				open = append(open, tok.Data)
+			}
+
+		case html.EndTagToken:
+			tok := z.Token()
+			if htmlDropWithContent[tok.Data] {
+				if skipDepth > 0 {
+					skipDepth--
+				}
+				continue
+			}
+			if skipDepth > 0 {
+				continue
+			}
+			// 只關閉確實開啟過的 tag，順便關閉中間沒有關閉的 tag
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != tok.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					sb.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+}
+
+func renderSanitizedTag(tok html.Token, allowed map[string]bool) (string, bool) {
+	var sb strings.Builder
+	sb.WriteString("<" + tok.Data)
+
+	hasSrc := false
+	blankTarget := false
+	for _, attr := range tok.Attr {
+		key := strings.ToLower(attr.Key)
+		if !allowed[key] || attr.Namespace != "" {
+			continue
+		}
+		value := attr.Val
+
+		switch key {
+		case "href", "src":
+			if !IsSafeURL(value) {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			if key == "src" {
+				hasSrc = true
+			}
+		case "class":
+			if !htmlClassRegex.MatchString(value) {
+				continue
+			}
+		case "width", "height", "colspan", "rowspan", "start":
+			if !htmlNumberRegex.MatchString(value) {
+				continue
+			}
+		case "target":
+			if value != "_blank" {
+				continue
+			}
+			blankTarget = true
+		case "rel":
+			// rel 由 target 決定
+			continue
+		}
+		sb.WriteString(" " + key + `="` + html.EscapeString(value) + `"`)
+	}
+
+	// 圖片沒有安全的 src 就不輸出
+	if tok.Data == "img" && !hasSrc {
+		return "", false
+	}
+	if blankTarget {
+		sb.WriteString(` rel="noopener noreferrer"`)
+	}
+	sb.WriteString(">")
+	return sb.String(), true
+}
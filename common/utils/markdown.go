@@ -0,0 +1,250 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// EditorJsBlockOut 產生 Editor.js JSON 時使用的 block
+type EditorJsBlockOut struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+type editorJsDocument struct {
+	Time   int64              `json:"time"`
+	Blocks []EditorJsBlockOut `json:"blocks"`
+}
+
+// 圖片 block 的 data，file.url 的格式要跟 extractImageUrls 抓取的一致
+type editorJsImageData struct {
+	File struct {
+		URL string `json:"url"`
+	} `json:"file"`
+	Caption        string `json:"caption"`
+	WithBorder     bool   `json:"withBorder"`
+	Stretched      bool   `json:"stretched"`
+	WithBackground bool   `json:"withBackground"`
+}
+
+// MarshalEditorJS 把 blocks 組成 Editor.js JSON（不跳脫 <、>，保留 inline HTML 原樣）
+func MarshalEditorJS(blocks []EditorJsBlockOut) (string, error) {
+	if blocks == nil {
+		blocks = []EditorJsBlockOut{}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(editorJsDocument{Time: time.Now().UnixMilli(), Blocks: blocks}); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// SplitFrontMatter 拆出開頭以 --- 包住的 YAML front matter，沒有 front matter 時回傳 nil
+func SplitFrontMatter(markdown string) (frontMatter []byte, body string) {
+	text := strings.TrimPrefix(markdown, "\uFEFF")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if !strings.HasPrefix(text, "---\n") {
+		return nil, text
+	}
+	rest := text[len("---\n"):]
+	for offset := 0; offset <= len(rest); {
+		end := strings.IndexByte(rest[offset:], '\n')
+		line := rest[offset:]
+		if end >= 0 {
+			line = rest[offset : offset+end]
+		}
+		if strings.TrimRight(line, " \t") == "---" {
+			body = ""
+			if end >= 0 {
+				body = rest[offset+end+1:]
+			}
+			return []byte(rest[:offset]), body
+		}
+		if end < 0 {
+			break
+		}
+		offset += end + 1
+	}
+	// 沒有結尾的 ---，視為沒有 front matter
+	return nil, text
+}
+
+var (
+	mdHeadingRegex     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdFenceRegex       = regexp.MustCompile("^(```|~~~)\\s*([\\w+-]*)\\s*$")
+	mdUnorderedRegex   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	mdOrderedRegex     = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	mdImageLineRegex   = regexp.MustCompile(`^!\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)$`)
+	mdRuleRegex        = regexp.MustCompile(`^(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	mdInlineCodeRegex  = regexp.MustCompile("`([^`]+)`")
+	mdInlineImageRegex = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	mdLinkRegex        = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	mdBoldRegex        = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	mdItalicRegex      = regexp.MustCompile(`(^|[^\w*])[*_]([^*_\s][^*_]*?)[*_]($|[^\w*])`)
+	mdStrikeRegex      = regexp.MustCompile(`~~(.+?)~~`)
+)
+
+// MarkdownToEditorJSBlocks 把 Markdown 轉成 Editor.js blocks
+// 支援標題、段落、清單、程式碼區塊、引言、圖片與分隔線，inline 語法會轉成 Editor.js 使用的 HTML tag
+func MarkdownToEditorJSBlocks(markdown string) []EditorJsBlockOut {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	blocks := []EditorJsBlockOut{}
+
+	var paragraph []string
+	flushParagraph := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		text := markdownInlineToHTML(strings.Join(paragraph, "\n"))
+		blocks = append(blocks, EditorJsBlockOut{Type: "paragraph", Data: map[string]string{"text": text}})
+		paragraph = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flushParagraph()
+
+		case mdFenceRegex.MatchString(trimmed):
+			flushParagraph()
+			fence := mdFenceRegex.FindStringSubmatch(trimmed)[1]
+			var code []string
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != fence; i++ {
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, EditorJsBlockOut{Type: "code", Data: map[string]string{"code": strings.Join(code, "\n")}})
+
+		case mdHeadingRegex.MatchString(trimmed):
+			flushParagraph()
+			m := mdHeadingRegex.FindStringSubmatch(trimmed)
+			blocks = append(blocks, EditorJsBlockOut{Type: "header", Data: map[string]interface{}{
+				"text":  markdownInlineToHTML(m[2]),
+				"level": len(m[1]),
+			}})
+
+		case mdImageLineRegex.MatchString(trimmed):
+			flushParagraph()
+			m := mdImageLineRegex.FindStringSubmatch(trimmed)
+			var data editorJsImageData
+			data.File.URL = m[2]
+			data.Caption = html.EscapeString(m[1])
+			blocks = append(blocks, EditorJsBlockOut{Type: "image", Data: data})
+
+		case mdRuleRegex.MatchString(trimmed):
+			flushParagraph()
+			blocks = append(blocks, EditorJsBlockOut{Type: "delimiter", Data: map[string]string{}})
+
+		case strings.HasPrefix(trimmed, ">"):
+			flushParagraph()
+			var quote []string
+			for ; i < len(lines); i++ {
+				t := strings.TrimSpace(lines[i])
+				if !strings.HasPrefix(t, ">") {
+					i--
+					break
+				}
+				quote = append(quote, strings.TrimSpace(strings.TrimPrefix(t, ">")))
+			}
+			blocks = append(blocks, EditorJsBlockOut{Type: "quote", Data: map[string]string{
+				"text":      markdownInlineToHTML(strings.TrimSpace(strings.Join(quote, "\n"))),
+				"caption":   "",
+				"alignment": "left",
+			}})
+
+		case mdUnorderedRegex.MatchString(line) || mdOrderedRegex.MatchString(line):
+			flushParagraph()
+			ordered := !mdUnorderedRegex.MatchString(line)
+			itemRegex := mdUnorderedRegex
+			style := "unordered"
+			if ordered {
+				itemRegex = mdOrderedRegex
+				style = "ordered"
+			}
+			items := []string{}
+			for ; i < len(lines); i++ {
+				m := itemRegex.FindStringSubmatch(lines[i])
+				if m == nil {
+					// 縮排的續行併入上一個項目
+					if len(items) > 0 && strings.TrimSpace(lines[i]) != "" && strings.HasPrefix(lines[i], " ") {
+						items[len(items)-1] += "<br>" + markdownInlineToHTML(strings.TrimSpace(lines[i]))
+						continue
+					}
+					i--
+					break
+				}
+				items = append(items, markdownInlineToHTML(m[1]))
+			}
+			blocks = append(blocks, EditorJsBlockOut{Type: "list", Data: map[string]interface{}{
+				"style": style,
+				"items": items,
+			}})
+
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flushParagraph()
+
+	return blocks
+}
+
+// MarkdownToEditorJS 把 Markdown 轉成 Editor.js JSON 字串
+func MarkdownToEditorJS(markdown string) (string, error) {
+	return MarshalEditorJS(MarkdownToEditorJSBlocks(markdown))
+}
+
+// 把 inline 語法轉成 Editor.js 的 HTML（<b>、<i>、<s>、<code class="inline-code">、<a>）
+// inline code 與連結先換成佔位符，其餘文字做 HTML 跳脫後再處理粗體、斜體等語法
+func markdownInlineToHTML(text string) string {
+	var tokens []string
+	placeholder := func(html string) string {
+		tokens = append(tokens, html)
+		return fmt.Sprintf("\x00%d\x00", len(tokens)-1)
+	}
+
+	text = mdInlineCodeRegex.ReplaceAllStringFunc(text, func(m string) string {
+		code := mdInlineCodeRegex.FindStringSubmatch(m)[1]
+		return placeholder(`<code class="inline-code">` + html.EscapeString(code) + `</code>`)
+	})
+	// 段落中的圖片無法放進文字 block，轉成連結保留網址
+	text = mdInlineImageRegex.ReplaceAllStringFunc(text, func(m string) string {
+		sub := mdInlineImageRegex.FindStringSubmatch(m)
+		label := sub[1]
+		if label == "" {
+			label = sub[2]
+		}
+		return placeholder(`<a href="` + html.EscapeString(sub[2]) + `">` + html.EscapeString(label) + `</a>`)
+	})
+	text = mdLinkRegex.ReplaceAllStringFunc(text, func(m string) string {
+		sub := mdLinkRegex.FindStringSubmatch(m)
+		return placeholder(`<a href="` + html.EscapeString(sub[2]) + `">` + markdownInlineToHTML(sub[1]) + `</a>`)
+	})
+
+	text = html.EscapeString(text)
+	text = mdBoldRegex.ReplaceAllString(text, `<b>$1$2</b>`)
+	// 相鄰的斜體會共用邊界字元，需要多跑幾次
+	for i := 0; i < 3; i++ {
+		next := mdItalicRegex.ReplaceAllString(text, `$1<i>$2</i>$3`)
+		if next == text {
+			break
+		}
+		text = next
+	}
+	text = mdStrikeRegex.ReplaceAllString(text, `<s>$1</s>`)
+	text = strings.ReplaceAll(text, "\n", " ")
+
+	for i := len(tokens) - 1; i >= 0; i-- {
+		text = strings.Replace(text, fmt.Sprintf("\x00%d\x00", i), tokens[i], 1)
+	}
+	return text
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"blog-backend/common/entity"
+
+	"github.com/uptrace/bun"
+)
+
+// 垃圾桶預設保留天數
+const defaultTrashRetentionDays = 30
+
+// ErrPostNotInTrash 文章不存在或尚未被移到垃圾桶
+var ErrPostNotInTrash = errors.New("post is not in trash")
+
+// TrashRetention 回傳軟刪除文章在垃圾桶保留的時間（TRASH_RETENTION_DAYS，預設 30 天）
+func TrashRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		days = defaultTrashRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// HardDeletePost 永久刪除已在垃圾桶的文章與相關資料，請在交易中呼叫
+// 圖片紀錄會保留：刪除時已標記為 pending_delete，交給 CleanPendingImages 從 R2 移除
+func HardDeletePost(ctx context.Context, db bun.IDB, postID uint) error {
+	inTrash, err := db.NewSelect().
+		Model((*entity.Post)(nil)).
+		Where("id = ?", postID).
+		Where("is_deleted = TRUE").
+		Exists(ctx)
+	if err != nil {
+		return err
+	}
+	if !inTrash {
+		return ErrPostNotInTrash
+	}
+
+	related := []interface{}{
+		(*entity.PostTag)(nil),
+		(*entity.PostRevision)(nil),
+		(*entity.PostSearchIndex)(nil),
+		(*entity.PreviewToken)(nil),
+		(*entity.PostView)(nil),
+		(*entity.PostViewDaily)(nil),
+		(*entity.Comment)(nil),
+		(*entity.PostNotification)(nil),
+	}
+	for _, model := range related {
+		_, err := db.NewDelete().
+			Model(model).
+			Where("post_id = ?", postID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 相關文章兩個方向都要刪
+	_, err = db.NewDelete().
+		Model((*entity.PostRelated)(nil)).
+		Where("post_id = ? OR related_post_id = ?", postID, postID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := DeleteSlugHistory(ctx, db, SlugEntityPost, postID); err != nil {
+		return err
+	}
+
+	_, err = db.NewDelete().
+		Model((*entity.Post)(nil)).
+		Where("id = ?", postID).
+		Exec(ctx)
+	return err
+}
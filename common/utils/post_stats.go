@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"unicode"
+
+	"blog-backend/common/entity"
+)
+
+// 預估閱讀速度：中日韓文字每分鐘 400 字、英文每分鐘 200 字
+const (
+	cjkCharsPerMinute   = 400
+	latinWordsPerMinute = 200
+)
+
+// 自動產生的摘要長度
+const SummaryLength = 200
+
+// PostStats 儲存文章時從內容計算出來的資料
+type PostStats struct {
+	Toc            []entity.TocItem
+	WordCount      int
+	ReadingMinutes int
+}
+
+// ComputePostStats 從 Editor.js 內容產生目錄、字數與預估閱讀時間
+func ComputePostStats(jsonContent string) PostStats {
+	blocks, err := ParseEditorJsBlocks(jsonContent)
+	if err != nil {
+		return PostStats{Toc: []entity.TocItem{}}
+	}
+
+	cjk, latin := CountWords(ExtractPlainTextFromEditorJS(jsonContent))
+	minutes := 0
+	if cjk+latin > 0 {
+		// 無條件進位，至少 1 分鐘
+		minutes = int(math.Ceil(float64(cjk)/cjkCharsPerMinute + float64(latin)/latinWordsPerMinute))
+	}
+
+	return PostStats{
+		Toc:            BuildToc(blocks),
+		WordCount:      cjk + latin,
+		ReadingMinutes: minutes,
+	}
+}
+
+// ApplyPostStats 依照文章內容填入儲存時計算的欄位（目錄、字數、閱讀時間與摘要）
+// 有手寫摘要（Excerpt）時以手寫摘要為準
+func ApplyPostStats(post *entity.Post) {
+	stats := ComputePostStats(post.Content)
+	post.Toc = stats.Toc
+	post.WordCount = stats.WordCount
+	post.ReadingMinutes = stats.ReadingMinutes
+
+	post.Summary = strings.TrimSpace(post.Excerpt)
+	if post.Summary == "" {
+		post.Summary = ExtractSummaryFromEditorJS(post.Content, SummaryLength)
+	}
+}
+
+// CountWords 計算字數：中日韓文字每個字算一個，其他文字以空白或標點分隔的單字計算
+func CountWords(text string) (cjk int, latin int) {
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || (inWord && (r == '\'' || r == '’' || r == '-')):
+			if !inWord {
+				latin++
+				inWord = true
+			}
+		default:
+			inWord = false
+		}
+	}
+	return cjk, latin
+}
+
+// BuildToc 依照 header block 的層級組成巢狀目錄
+func BuildToc(blocks []EditorJsRawBlock) []entity.TocItem {
+	anchors := HeaderAnchors(blocks)
+
+	root := []entity.TocItem{}
+	// stack 內是目前路徑上每一層的 children 指標與層級
+	type frame struct {
+		level int
+		items *[]entity.TocItem
+	}
+	stack := []frame{{level: 0, items: &root}}
+
+	for i, block := range blocks {
+		if block.Type != "header" {
+			continue
+		}
+		level, text := headerLevelAndText(block)
+		if text == "" {
+			continue
+		}
+
+		for len(stack) > 1 && stack[len(stack)-1].level >= level {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1].items
+		*parent = append(*parent, entity.TocItem{
+			ID:       anchors[i],
+			Text:     text,
+			Level:    level,
+			Children: []entity.TocItem{},
+		})
+		last := &(*parent)[len(*parent)-1]
+		stack = append(stack, frame{level: level, items: &last.Children})
+	}
+	return root
+}
+
+// HeaderAnchors 替每個 header block 產生 anchor id（以 block 的位置為 key）
+// 目錄與 HTML 輸出都使用這個函式，id 由標題文字產生，重複時依序加上 -2、-3
+func HeaderAnchors(blocks []EditorJsRawBlock) map[int]string {
+	anchors := make(map[int]string)
+	used := make(map[string]bool)
+
+	for i, block := range blocks {
+		if block.Type != "header" {
+			continue
+		}
+		_, text := headerLevelAndText(block)
+		base := anchorSlug(text)
+		if base == "" {
+			base = "section"
+		}
+
+		id := base
+		for n := 2; used[id]; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[id] = true
+		anchors[i] = id
+	}
+	return anchors
+}
+
+func headerLevelAndText(block EditorJsRawBlock) (int, string) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(block.Data, &data); err != nil {
+		return 0, ""
+	}
+	level := intField(data, "level")
+	if level < 1 || level > 6 {
+		level = 2
+	}
+	text := strings.TrimSpace(html.UnescapeString(stripHTMLTags(stringField(data, "text"))))
+	return level, text
+}
+
+// anchor id：保留各種語言的文字與數字（中文標題也能有可讀的 id），其他字元轉成連字號
+func anchorSlug(text string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return sb.String()
+}
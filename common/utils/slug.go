@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// slug 只允許小寫英數字，並以單一連字號分隔
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// regex: 非英數字的連續字元，轉成連字號
+var slugSeparatorRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// IsValidSlug 檢查 slug 格式是否合法
+func IsValidSlug(slug string) bool {
+	return slugPattern.MatchString(slug)
+}
+
+// Slugify 將名稱轉成 slug（中文等非英數字會被移除，可能回傳空字串）
+func Slugify(input string) string {
+	slug := strings.ToLower(strings.TrimSpace(input))
+	slug = slugSeparatorRegex.ReplaceAllString(slug, "-")
+	return strings.Trim(slug, "-")
+}
+
+// UniqueSlug 以 base 為基礎，遇到重複時依序加上 -2、-3… 直到 exists 回傳 false
+func UniqueSlug(base string, exists func(slug string) (bool, error)) (string, error) {
+	candidate := base
+	for i := 2; ; i++ {
+		taken, err := exists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+}
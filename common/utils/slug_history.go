@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"blog-backend/common/entity"
+
+	"github.com/uptrace/bun"
+)
+
+// slug 歷史紀錄的資源類型
+const (
+	SlugEntityPost     = "post"
+	SlugEntityCategory = "category"
+)
+
+// IsSlugTaken 檢查 slug 是否已被同類型的其他資源使用（包含目前的 slug 與歷史 slug）
+// entityID 為自己的 ID（新增時傳 0），自己用過的舊 slug 可以再拿回來用
+func IsSlugTaken(ctx context.Context, db bun.IDB, entityType string, entityID uint, slug string) (bool, error) {
+	var current interface{}
+	switch entityType {
+	case SlugEntityPost:
+		current = (*entity.Post)(nil)
+	case SlugEntityCategory:
+		current = (*entity.Category)(nil)
+	default:
+		return false, errors.New("unknown slug entity type: " + entityType)
+	}
+
+	count, err := db.NewSelect().
+		Model(current).
+		Where("slug = ?", slug).
+		Where("id != ?", entityID).
+		Count(ctx)
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+
+	count, err = db.NewSelect().
+		Model((*entity.SlugHistory)(nil)).
+		Where("entity_type = ?", entityType).
+		Where("slug = ?", slug).
+		Where("entity_id != ?", entityID).
+		Count(ctx)
+	return count > 0, err
+}
+
+// RecordSlugChange 記錄被換掉的舊 slug；如果新 slug 是自己以前用過的，就把那筆歷史移除
+func RecordSlugChange(ctx context.Context, db bun.IDB, entityType string, entityID uint, oldSlug, newSlug string) error {
+	if oldSlug == newSlug {
+		return nil
+	}
+
+	_, err := db.NewDelete().
+		Model((*entity.SlugHistory)(nil)).
+		Where("entity_type = ?", entityType).
+		Where("entity_id = ?", entityID).
+		Where("slug = ?", newSlug).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if oldSlug == "" {
+		return nil
+	}
+
+	_, err = db.NewInsert().
+		Model(&entity.SlugHistory{
+			EntityType: entityType,
+			EntityID:   entityID,
+			Slug:       oldSlug,
+			CreatedAt:  time.Now(),
+		}).
+		On("CONFLICT (entity_type, slug) DO UPDATE").
+		Set("entity_id = EXCLUDED.entity_id").
+		Set("created_at = EXCLUDED.created_at").
+		Exec(ctx)
+	return err
+}
+
+// FindSlugRedirect 以舊 slug 找出資源 ID，找不到時 found 為 false
+func FindSlugRedirect(ctx context.Context, db bun.IDB, entityType, slug string) (entityID uint, found bool, err error) {
+	var history entity.SlugHistory
+	err = db.NewSelect().
+		Model(&history).
+		Where("entity_type = ?", entityType).
+		Where("slug = ?", slug).
+		Limit(1).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+	return history.EntityID, true, nil
+}
+
+// DeleteSlugHistory 資源被永久刪除時，一併移除它的 slug 歷史
+func DeleteSlugHistory(ctx context.Context, db bun.IDB, entityType string, entityID uint) error {
+	_, err := db.NewDelete().
+		Model((*entity.SlugHistory)(nil)).
+		Where("entity_type = ?", entityType).
+		Where("entity_id = ?", entityID).
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"strings"
+	"time"
+
+	"blog-backend/common/entity"
+	"blog-backend/common/seo"
+)
+
+// 事件名稱，訂閱時可以用「類別.*」訂閱整個類別，或用「*」訂閱全部
+const (
+	EventPostCreated   = "post.created"   // 新增文章（不論是否發佈）
+	EventPostPublished = "post.published" // 文章從未發佈變成發佈（含排程發佈）
+	EventPostUpdated   = "post.updated"   // 文章內容或狀態改變（含下架、移動分類、從垃圾桶還原）
+	EventPostDeleted   = "post.deleted"   // 文章移到垃圾桶
+
+	EventCategoryCreated   = "category.created"
+	EventCategoryUpdated   = "category.updated"
+	EventCategoryMoved     = "category.moved"
+	EventCategoryReordered = "category.reordered"
+	EventCategoryDeleted   = "category.deleted"
+
+	EventAboutUpdated = "about.updated"
+)
+
+// Events 所有事件，後台驗證訂閱的事件時使用
+var Events = []string{
+	EventPostCreated, EventPostPublished, EventPostUpdated, EventPostDeleted,
+	EventCategoryCreated, EventCategoryUpdated, EventCategoryMoved, EventCategoryReordered, EventCategoryDeleted,
+	EventAboutUpdated,
+}
+
+// ValidFilter 檢查訂閱的事件是否存在：完整事件名稱、「類別.*」或「*」
+func ValidFilter(filter string) bool {
+	if filter == "*" {
+		return true
+	}
+	for _, event := range Events {
+		if filter == event || filter == eventGroup(event)+".*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Match 事件是否符合任一個訂閱條件
+func Match(filters []string, event string) bool {
+	for _, filter := range filters {
+		if filter == "*" || filter == event || filter == eventGroup(event)+".*" {
+			return true
+		}
+	}
+	return false
+}
+
+func eventGroup(event string) string {
+	group, _, _ := strings.Cut(event, ".")
+	return group
+}
+
+// PostData 文章事件的 data
+type PostData struct {
+	ID          uint      `json:"id"`
+	Title       string    `json:"title"`
+	Slug        string    `json:"slug"`
+	URL         string    `json:"url"` // 前台文章網址
+	CategoryID  uint      `json:"categoryId"`
+	IsPublished bool      `json:"isPublished"`
+	IsDeleted   bool      `json:"isDeleted"`
+	Summary     string    `json:"summary"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func NewPostData(post entity.Post) PostData {
+	return PostData{
+		ID:          post.ID,
+		Title:       post.Title,
+		Slug:        post.Slug,
+		URL:         seo.PostURL(post.Slug),
+		CategoryID:  post.CategoryID,
+		IsPublished: post.IsPublished,
+		IsDeleted:   post.IsDeleted,
+		Summary:     post.Summary,
+		UpdatedAt:   post.UpdatedAt,
+	}
+}
+
+// CategoryData 分類事件的 data
+type CategoryData struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	URL       string `json:"url"`    // 前台分類頁網址
+	Parent    *uint  `json:"parent"` // 上層分類 ID，最上層為 null
+	SortOrder int    `json:"sortOrder"`
+}
+
+func NewCategoryData(category entity.Category) CategoryData {
+	return CategoryData{
+		ID:        category.ID,
+		Name:      category.Name,
+		Slug:      category.Slug,
+		URL:       seo.CategoryURL(category.Slug),
+		Parent:    category.Parent,
+		SortOrder: category.SortOrder,
+	}
+}
+
+// CategoryReorderData category.reordered 的 data
+type CategoryReorderData struct {
+	Parent *uint  `json:"parent"` // 重新排序的是哪一層，最上層為 null
+	IDs    []uint `json:"ids"`    // 新的順序
+}
+
+// AboutData about.updated 的 data
+type AboutData struct {
+	ID        uint      `json:"id"`
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
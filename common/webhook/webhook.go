@@ -0,0 +1,293 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"blog-backend/common/entity"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// 送出的 header；簽章為 HMAC-SHA256(secret, "<timestamp>.<body>")，接收端要檢查時間戳避免重放
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// 重試間隔從 1 分鐘開始每次加倍，最長 12 小時；預設最多嘗試 10 次（WEBHOOK_MAX_ATTEMPTS 可調整）
+const (
+	defaultMaxAttempts = 10
+	retryBaseDelay     = time.Minute
+	retryMaxDelay      = 12 * time.Hour
+
+	// 開始傳送時先把下次嘗試時間往後延，避免 batch 與發送事件的請求同時送出同一筆
+	sendLease = 2 * time.Minute
+)
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Payload 送出的 JSON 內容，同一個事件送給每個 webhook 的 ID 都相同
+type Payload struct {
+	ID         string      `json:"id"`
+	Event      string      `json:"event"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"`
+}
+
+// Enqueue 替訂閱此事件的 webhook 建立傳送紀錄（與內容異動在同一個交易中），提交後再呼叫 Deliver
+func Enqueue(ctx context.Context, db bun.IDB, event string, data interface{}) ([]int64, error) {
+	var subscriptions []entity.WebhookSubscription
+	err := db.NewSelect().
+		Model(&subscriptions).
+		Where("is_active = TRUE").
+		OrderExpr("id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	deliveries := []entity.WebhookDelivery{}
+	var body []byte
+	for _, subscription := range subscriptions {
+		if !Match(subscription.Events, event) {
+			continue
+		}
+		if body == nil {
+			body, err = json.Marshal(Payload{ID: uuid.NewString(), Event: event, OccurredAt: now, Data: data})
+			if err != nil {
+				return nil, err
+			}
+		}
+		deliveries = append(deliveries, entity.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			Event:          event,
+			Payload:        string(body),
+			Status:         entity.WebhookDeliveryPending,
+			NextAttemptAt:  &now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+
+	if _, err := db.NewInsert().Model(&deliveries).Returning("id").Exec(ctx); err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.ID)
+	}
+	return ids, nil
+}
+
+// Deliver 傳送指定的紀錄，還沒到重試時間或已經完成的會略過，回傳成功送達的數量
+func Deliver(ctx context.Context, db bun.IDB, ids []int64) (int, error) {
+	succeeded := 0
+	for _, id := range ids {
+		ok, err := attempt(ctx, db, id)
+		if err != nil {
+			return succeeded, err
+		}
+		if ok {
+			succeeded++
+		}
+	}
+	return succeeded, nil
+}
+
+// DeliverDue 傳送所有到了重試時間的紀錄（batch 定期呼叫），回傳成功送達的數量
+func DeliverDue(ctx context.Context, db bun.IDB) (int, error) {
+	var ids []int64
+	err := db.NewSelect().
+		Model((*entity.WebhookDelivery)(nil)).
+		Column("id").
+		Where("status = ?", entity.WebhookDeliveryPending).
+		Where("next_attempt_at <= NOW()").
+		OrderExpr("next_attempt_at ASC, id ASC").
+		Scan(ctx, &ids)
+	if err != nil {
+		return 0, err
+	}
+	return Deliver(ctx, db, ids)
+}
+
+// Redeliver 手動重送一筆紀錄（不論之前成功或失敗），回傳重送後的紀錄
+func Redeliver(ctx context.Context, db bun.IDB, id int64) (entity.WebhookDelivery, error) {
+	res, err := db.NewUpdate().
+		Model((*entity.WebhookDelivery)(nil)).
+		Set("status = ?", entity.WebhookDeliveryPending).
+		Set("next_attempt_at = NOW()").
+		Set("updated_at = NOW()").
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return entity.WebhookDelivery{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return entity.WebhookDelivery{}, sql.ErrNoRows
+	}
+
+	if _, err := attempt(ctx, db, id); err != nil {
+		return entity.WebhookDelivery{}, err
+	}
+
+	var delivery entity.WebhookDelivery
+	err = db.NewSelect().
+		Model(&delivery).
+		Where("id = ?", id).
+		Scan(ctx)
+	return delivery, err
+}
+
+// NewSecret 產生新的簽章金鑰
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// Sign 計算 X-Webhook-Signature 的值
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// 嘗試傳送一次並記錄結果，回傳是否成功送達；只有資料庫錯誤會回傳 error
+func attempt(ctx context.Context, db bun.IDB, id int64) (bool, error) {
+	// 取得傳送資格：同一筆紀錄同時只會有一個地方在送
+	var delivery entity.WebhookDelivery
+	err := db.NewUpdate().
+		Model(&delivery).
+		Set("next_attempt_at = ?", time.Now().Add(sendLease)).
+		Where("id = ?", id).
+		Where("status = ?", entity.WebhookDeliveryPending).
+		Where("next_attempt_at <= NOW()").
+		Returning("*").
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	var subscription entity.WebhookSubscription
+	err = db.NewSelect().
+		Model(&subscription).
+		Where("id = ?", delivery.SubscriptionID).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, finish(ctx, db, delivery, 0, errors.New("webhook 已刪除"), true)
+	} else if err != nil {
+		return false, err
+	}
+
+	status, sendErr := send(ctx, subscription, delivery)
+	if sendErr != nil {
+		fmt.Printf("⚠️ webhook 傳送失敗：紀錄 ID=%d，%s，第 %d 次，錯誤：%v\n", delivery.ID, subscription.URL, delivery.Attempts+1, sendErr)
+	}
+	return sendErr == nil, finish(ctx, db, delivery, status, sendErr, false)
+}
+
+func send(ctx context.Context, subscription entity.WebhookSubscription, delivery entity.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("建立請求失敗：%v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "blog-backend-webhook")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, timestamp, body))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// 只保留回應的開頭，方便在傳送紀錄中查看原因
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("回傳非預期狀態碼 %d：%s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	return resp.StatusCode, nil
+}
+
+// 記錄這次嘗試的結果：成功、排定下次重試，或次數用完（giveUp 時直接放棄）
+func finish(ctx context.Context, db bun.IDB, delivery entity.WebhookDelivery, status int, sendErr error, giveUp bool) error {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	delivery.UpdatedAt = now
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = entity.WebhookDeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+	case giveUp || delivery.Attempts >= maxAttempts():
+		delivery.Status = entity.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.LastError = sendErr.Error()
+	default:
+		next := now.Add(RetryDelay(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+		delivery.LastError = sendErr.Error()
+	}
+
+	_, err := db.NewUpdate().
+		Model(&delivery).
+		Column("status", "attempts", "next_attempt_at", "response_status", "last_error", "delivered_at", "updated_at").
+		WherePK().
+		Exec(ctx)
+	return err
+}
+
+// RetryDelay 第 attempts 次失敗後到下次重試的間隔（指數退避）
+func RetryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
+
+func maxAttempts() int {
+	n, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS"))
+	if err != nil || n <= 0 {
+		return defaultMaxAttempts
+	}
+	return n
+}